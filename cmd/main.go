@@ -1,9 +1,14 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/palemoky/lucky-day/internal/checkin"
 	"github.com/palemoky/lucky-day/internal/config"
@@ -12,11 +17,46 @@ import (
 	"github.com/palemoky/lucky-day/internal/lottery"
 	"github.com/palemoky/lucky-day/internal/model"
 	"github.com/palemoky/lucky-day/internal/tui"
+	"github.com/palemoky/lucky-day/internal/validation"
+)
+
+const (
+	// revealFile is where the seed and draw log are revealed after the event
+	revealFile = "lottery_reveal.json"
+	// journalFile records every operation as it happens so a crashed session can be resumed
+	journalFile = "lottery_journal.jsonl"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "verify":
+			os.Exit(runVerify(os.Args[2:]))
+		case "simulate":
+			os.Exit(runSimulate(os.Args[2:]))
+		}
+	}
+
+	seedFlag := flag.Int64("seed", 0, "random seed for the draw; pass a recorded seed to replay a previous event")
+	flag.Parse()
+
+	// Record the seed before anything else so the draw can be replayed later
+	seed := lottery.NewSeed()
+	if isFlagSet("seed") {
+		seed = *seedFlag
+	}
+
+	// A journal left behind by an interrupted run can be resumed, unless every
+	// prize was already drawn. A journal that fails to replay is still offered
+	// so that resuming reports why.
+	weights, err := loadWeightStrategy(".")
+	if err != nil {
+		weights = nil
+	}
+	pending, journalErr := lottery.JournalPending(journalFile, weights)
+
 	// Unified startup flow - no screen flicker!
-	selectedLang, selectedMode, quit, err := tui.RunStartupFlow()
+	selectedLang, selectedMode, quit, err := tui.RunStartupFlow(pending || journalErr != nil)
 	if err != nil {
 		log.Fatalf("Startup failed: %v", err)
 	}
@@ -26,78 +66,380 @@ func main() {
 	}
 
 	translator := i18n.NewTranslator(selectedLang)
+	os.Exit(runSession(translator, selectedMode, seed))
+}
 
-	var participants []model.Participant
-	var prizes []model.Prize
+// errQuit reports that the operator left before the draw started. The goodbye
+// message has already been shown.
+var errQuit = errors.New("quit")
+
+// runSession runs the draw and returns the exit code. Setup errors come back
+// here instead of exiting where they happen, so the data source and the
+// journal are always closed.
+func runSession(translator *i18n.Translator, mode tui.LotteryMode, seed int64) int {
+	var (
+		engine *lottery.Engine
+		source datasource.Source // Supplies the roster and prizes, and keeps the winners
+		err    error
+	)
+	if mode == tui.ModeResume {
+		engine, source, err = resumeSession(translator)
+	} else {
+		engine, source, err = newSession(translator, mode, seed)
+	}
+	if errors.Is(err, errQuit) {
+		return 0
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	defer func() { _ = engine.CloseJournal() }()
+	defer func() { _ = source.Close() }()
+
+	// Step 5: Start TUI, saving the winners after every draw so nothing is lost if the program dies
+	save := newWinnerSaver(engine, source)
+	tuiErr := tui.StartTUI(engine, save)
+
+	// Reveal the seed and draw log so anyone can verify the results offline. While
+	// prizes are left the session can be resumed, and the seed would predict the rest.
+	if !engine.Finished() {
+		fmt.Println(translator.T("fairness.reveal_held"))
+	} else if err := lottery.WriteReveal(revealFile, engine.Reveal()); err != nil {
+		fmt.Printf("%s: %v\n", translator.T("fairness.reveal_failed"), err)
+	} else {
+		fmt.Printf("%s: %s\n", translator.T("fairness.reveal_saved"), revealFile)
+	}
 
+	if err := save(); err != nil {
+		fmt.Printf("%s: %v\n", translator.T("winner.save_failed"), err)
+	} else {
+		fmt.Println(translator.T("winner.save_success"))
+	}
+
+	if tuiErr != nil {
+		fmt.Printf("%s: %v\n", translator.T("app.error"), tuiErr)
+		return 1
+	}
+
+	fmt.Println(translator.T("app.exit"))
+	return 0
+}
+
+// newSession loads the roster and prizes for the selected mode, publishes the
+// commitment and starts a fresh session journal. It returns the engine and the
+// data source that keeps the winners.
+func newSession(translator *i18n.Translator, mode tui.LotteryMode, seed int64) (*lottery.Engine, datasource.Source, error) {
 	// Step 3: Load data based on selected mode
-	switch selectedMode {
-	case tui.ModeExcel:
-		// Load from Excel
-		prizes, participants, err = loadFromExcel(translator)
+	source, err := openSource(translator, mode, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", translator.T("data.load_failed"), err)
+	}
+	engine, err := startEngine(translator, mode, source, seed)
+	if err != nil {
+		_ = source.Close()
+		return nil, nil, err
+	}
+	return engine, source, nil
+}
+
+// startEngine validates the data loaded from source, then builds the engine,
+// publishes its commitment and starts the journal
+func startEngine(translator *i18n.Translator, mode tui.LotteryMode, source datasource.Source, seed int64) (*lottery.Engine, error) {
+	prizes, err := source.LoadPrizes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", translator.T("data.load_failed"), err)
+	}
+	participants, err := source.LoadParticipants()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", translator.T("data.load_failed"), err)
+	}
+
+	if len(participants) == 0 {
+		return nil, errors.New(translator.T("data.empty_list"))
+	}
+
+	fmt.Printf("%s %d %s\n", translator.T("data.load_success"), len(participants), translator.T("data.participants"))
+	fmt.Printf("%s %d %s\n", translator.T("data.load_success"), len(prizes), translator.T("data.prizes"))
+
+	// Catch typos in eligibility and quota rules before the event starts
+	for _, prize := range prizes {
+		if err := lottery.ValidatePrize(prize); err != nil {
+			return nil, fmt.Errorf("%s: %w", translator.T("data.config_error"), err)
+		}
+	}
+
+	// Let the host review roster problems before anything is committed
+	report := validation.Check(participants, prizes, datasource.RowErrors(source))
+	if !report.Empty() {
+		proceed, err := tui.ShowValidationReport(translator, report)
 		if err != nil {
-			log.Fatalf("%s: %v", translator.T("data.load_failed"), err)
+			return nil, fmt.Errorf("%s: %w", translator.T("app.error"), err)
+		}
+		if !proceed {
+			fmt.Println(translator.T("validation.aborted"))
+			return nil, errQuit
+		}
+	}
+
+	weights, err := loadWeightStrategy(".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", translator.T("data.config_error"), err)
+	}
+	eventYear, err := config.LoadEventYear(".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", translator.T("data.config_error"), err)
+	}
+	// Step 4: Initialize lottery engine and publish the commitment before any draw.
+	// The seed itself stays secret until it is revealed after the event. Committing
+	// also fixes the event year and drops the winners an earlier run of this event saved.
+	engine := lottery.NewEngine(participants, prizes, seed)
+	engine.SetWeightStrategy(weights)
+	engine.SetEventYear(eventYear)
+	commitment, err := engine.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", translator.T("app.error"), err)
+	}
+	fmt.Printf("%s: %d\n", translator.T("fairness.event_year"), engine.EventYear())
+	fmt.Printf("%s: %s\n", translator.T("fairness.commitment"), commitment)
+
+	// Journal every operation so a crash mid-event can be resumed.
+	// The journal holds the seed, so keep it private until the reveal.
+	if err := archiveJournal(journalFile); err != nil {
+		return nil, fmt.Errorf("%s: %w", translator.T("journal.failed"), err)
+	}
+	if err := engine.StartJournal(journalFile, map[string]string{"mode": string(mode)}); err != nil {
+		return nil, fmt.Errorf("%s: %w", translator.T("journal.failed"), err)
+	}
+	fmt.Printf("%s: %s\n", translator.T("journal.saved"), journalFile)
+
+	return engine, nil
+}
+
+// resumeSession rebuilds the engine from the journal of an interrupted session and
+// reopens the data source of that session to keep saving its winners
+func resumeSession(translator *i18n.Translator) (*lottery.Engine, datasource.Source, error) {
+	weights, err := loadWeightStrategy(".")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", translator.T("data.config_error"), err)
+	}
+	engine, header, err := lottery.ResumeJournal(journalFile, weights)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", translator.T("journal.resume_failed"), err)
+	}
+	// The roster comes from the journal, so check-in is not repeated
+	source, err := openSource(translator, tui.LotteryMode(header.Meta["mode"]), false)
+	if err != nil {
+		_ = engine.CloseJournal()
+		return nil, nil, fmt.Errorf("%s: %w", translator.T("journal.resume_failed"), err)
+	}
+	fmt.Printf("%s: %s (%s)\n", translator.T("journal.resumed"), journalFile, header.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("%s: %d\n", translator.T("fairness.event_year"), engine.EventYear())
+	fmt.Printf("%s: %s\n", translator.T("fairness.commitment"), engine.Commitment())
+	return engine, source, nil
+}
+
+// openSource opens the data source of a lottery mode from the datasource section of
+// config.yml. Excel, CSV and database modes force their type; check-in mode takes the
+// prizes from the configured source and, when checkIn is set, runs the check-in to
+// collect the participants.
+func openSource(translator *i18n.Translator, mode tui.LotteryMode, checkIn bool) (datasource.Source, error) {
+	dsCfg, err := config.LoadDataSourceConfig(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	switch mode {
+	case tui.ModeExcel:
+		fmt.Println(translator.T("data.source_excel"))
+		if dsCfg.Type != "excel" {
+			dsCfg.Type = "excel"
+			if dsCfg.Excel.Path == "" {
+				dsCfg.Excel.Path = "lottery_template.xlsx"
+			}
+		}
+		return datasource.Open(dsCfg)
+
+	case tui.ModeCSV:
+		fmt.Println(translator.T("data.source_csv"))
+		if dsCfg.Type != "csv" {
+			dsCfg.Type = "csv"
+			if dsCfg.CSV.Path == "" {
+				dsCfg.CSV.Path = "participants.csv"
+			}
 		}
+		return datasource.Open(dsCfg)
+
+	case tui.ModeDB:
+		fmt.Println(translator.T("data.source_db"))
+		dsCfg.Type = "db"
+		return datasource.Open(dsCfg)
 
 	case tui.ModeQR:
-		// QR Check-in mode - run QR UI in continuation
-		prizes, participants, err = loadFromQRCheckInContinuous(translator)
+		if dsCfg.Type == "" {
+			dsCfg.Type = "excel"
+		}
+		if dsCfg.Type == "excel" && dsCfg.Excel.Path == "" {
+			dsCfg.Excel.Path = "examples/lottery_template.xlsx"
+		}
+		checkInCfg, err := config.LoadCheckIn(".")
 		if err != nil {
-			log.Fatalf("%s: %v", translator.T("data.load_failed"), err)
+			return nil, err
+		}
+		src, err := datasource.Open(dsCfg)
+		if err != nil {
+			return nil, err
+		}
+		if !checkIn {
+			// Resuming: the participants come from the journal
+			if checkInCfg.Roster {
+				return datasource.NewPresentSource(src, nil), nil
+			}
+			return datasource.NewCheckInSource(src, nil, datasource.DefaultWinnersFile), nil
 		}
 
-	case tui.ModeDB:
-		// Load from database
-		prizes, participants, err = loadFromDatabase(translator)
+		// With roster check-in, only the people on the roster can check in and
+		// they keep their IDs, so winners are saved back to the data source
+		var roster []model.Participant
+		if checkInCfg.Roster {
+			if roster, err = src.LoadParticipants(); err != nil {
+				_ = src.Close()
+				return nil, err
+			}
+		}
+		participants, err := runCheckIn(translator, checkInCfg, roster)
 		if err != nil {
-			log.Fatalf("%s: %v", translator.T("data.load_failed"), err)
+			_ = src.Close()
+			return nil, err
 		}
+		if checkInCfg.Roster {
+			return datasource.NewPresentSource(src, participants), nil
+		}
+		return datasource.NewCheckInSource(src, participants, datasource.DefaultWinnersFile), nil
 
 	default:
-		log.Fatalf("Unknown mode: %s", selectedMode)
+		return nil, fmt.Errorf("unknown mode: %s", mode)
 	}
+}
 
-	if len(participants) == 0 {
-		log.Fatal(translator.T("data.empty_list"))
+// archiveJournal moves the journal of a previous session aside so it is never overwritten
+func archiveJournal(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
 	}
-
-	fmt.Printf("%s %d %s\n", translator.T("data.load_success"), len(participants), translator.T("data.participants"))
-	fmt.Printf("%s %d %s\n", translator.T("data.load_success"), len(prizes), translator.T("data.prizes"))
-
-	// Step 4: Initialize lottery engine
-	engine := lottery.NewEngine(participants, prizes)
-
-	// Step 5: Start TUI
-	if err := tui.StartTUI(engine); err != nil {
-		fmt.Printf("%s: %v\n", translator.T("app.error"), err)
-		os.Exit(1)
+	if err != nil {
+		return err
 	}
+	ext := filepath.Ext(path)
+	archived := fmt.Sprintf("%s.%s%s", strings.TrimSuffix(path, ext), info.ModTime().Format("20060102-150405"), ext)
+	return os.Rename(path, archived)
+}
 
-	fmt.Println(translator.T("app.exit"))
+// isFlagSet reports whether the named flag was passed on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
-// loadFromQRCheckInContinuous starts QR check-in server in background
-func loadFromQRCheckInContinuous(translator *i18n.Translator) ([]model.Prize, []model.Participant, error) {
-	// Load prizes from Excel (we still need prizes configuration)
-	dsCfg, err := config.LoadDataSourceConfig(".")
+// loadWeightStrategy builds the weighting policy configured in config.yml
+func loadWeightStrategy(path string) (lottery.WeightStrategy, error) {
+	cfgs, err := config.LoadWeighting(path)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+		return nil, err
+	}
+	if len(cfgs) == 0 {
+		return lottery.DefaultWeightStrategy(), nil
 	}
 
-	excelPath := dsCfg.Excel.Path
-	if excelPath == "" {
-		excelPath = "examples/lottery_template.xlsx"
+	var combined lottery.CombinedWeight
+	for _, cfg := range cfgs {
+		strategy, err := lottery.NewWeightStrategy(cfg.Strategy, cfg.Params)
+		if err != nil {
+			return nil, err
+		}
+		combined = append(combined, strategy)
+	}
+	if len(combined) == 1 {
+		return combined[0], nil
 	}
+	return combined, nil
+}
 
-	prizes, err := datasource.LoadPrizesFromExcel(excelPath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load prizes: %w", err)
+// newWinnerSaver returns a function that writes every winner of the session to
+// the data source. Each call replaces the winners saved by the previous one, so
+// it can run after every draw, undo and reset.
+func newWinnerSaver(engine *lottery.Engine, source datasource.Source) tui.SaveFunc {
+	// A winner keeps the time they were first saved, not the time of the latest save
+	drawTimes := make(map[datasource.Winner]time.Time)
+	return func() error {
+		rows := winnersForExport(engine, time.Time{})
+		now := engine.Now()
+		for i := range rows {
+			if _, ok := drawTimes[rows[i]]; !ok {
+				drawTimes[rows[i]] = now
+			}
+			rows[i].DrawTime = drawTimes[rows[i]]
+		}
+		return source.SaveWinners(engine.Commitment(), rows)
+	}
+}
+
+// winnersForExport lists every winner by prize, followed by the winners who
+// forfeited that prize and were replaced by a redraw
+func winnersForExport(engine *lottery.Engine, drawTime time.Time) []datasource.Winner {
+	allWinners := engine.GetAllWinners()
+	forfeits := engine.GetForfeits()
+
+	var rows []datasource.Winner
+	for _, prize := range engine.GetPrizes() {
+		row := func(p model.Participant, status string) datasource.Winner {
+			return datasource.Winner{
+				DrawTime:   drawTime,
+				PrizeName:  prize.Name,
+				WinnerID:   p.ID,
+				WinnerName: p.Name,
+				PrizeLevel: int(prize.Level),
+				Status:     status,
+				EventYear:  engine.EventYear(),
+			}
+		}
+		for _, w := range allWinners[prize.ID] {
+			rows = append(rows, row(w, lottery.StatusWon))
+		}
+		for _, f := range forfeits {
+			if f.PrizeID == prize.ID {
+				rows = append(rows, row(f.Participant, lottery.StatusForfeited))
+			}
+		}
 	}
+	return rows
+}
 
+// runCheckIn starts the QR check-in server and collects participants until Enter is pressed
+func runCheckIn(translator *i18n.Translator, cfg config.CheckInConfig, roster []model.Participant) ([]model.Participant, error) {
 	// Start check-in server in background
-	server := checkin.NewServer(8888, translator)
+	server := checkin.NewServer(cfg.Port, translator)
+	server.SetBind(cfg.Bind)
+	server.SetBaseURL(cfg.BaseURL)
+	if cfg.Roster {
+		server.SetRoster(roster, cfg.Code)
+	}
+	host := cfg.Host
+	if host == "" && cfg.BaseURL == "" {
+		var err error
+		if host, err = chooseCheckInHost(translator); err != nil {
+			return nil, err
+		}
+	}
+	server.SetHost(host)
 	if err := server.Start(); err != nil {
-		return nil, nil, fmt.Errorf("failed to start check-in server: %w", err)
+		return nil, fmt.Errorf("failed to start check-in server: %w", err)
 	}
 
 	// Generate QR code
@@ -105,89 +447,60 @@ func loadFromQRCheckInContinuous(translator *i18n.Translator) ([]model.Prize, []
 	url := server.GetURL()
 	if err := checkin.GenerateQRCode(url, qrPath); err != nil {
 		_ = server.Stop() // Ignore error on cleanup
-		return nil, nil, fmt.Errorf("failed to generate QR code: %w", err)
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
 	}
 
-	// Show simple message - QR code is ready
-	fmt.Printf("\n✅ %s\n", translator.T("qr.ready"))
-	fmt.Printf("📱 %s: %s\n", translator.T("qr.url"), url)
-	fmt.Printf("🖼️  %s: %s\n\n", translator.T("qr.qr_file"), qrPath)
-	fmt.Printf("💡 %s\n", translator.T("qr.hint"))
-	fmt.Printf("⏎  %s\n\n", translator.T("qr.press_enter"))
-
-	// Wait for user to press Enter
-	_, _ = fmt.Scanln() // Ignore input
+	// Show the QR code in the terminal until the host starts the draw
+	start, err := tui.RunCheckIn(translator, server, qrPath)
 
 	// Stop server - no more check-ins allowed
 	_ = server.Stop() // Ignore error on shutdown
+	if err != nil {
+		return nil, err
+	}
+	if !start {
+		fmt.Println("Goodbye!")
+		return nil, errQuit
+	}
 
 	// Get participants
 	participants := server.GetParticipants()
 
 	if len(participants) == 0 {
-		return nil, nil, fmt.Errorf("%s", translator.T("qr.no_participants"))
+		return nil, fmt.Errorf("%s", translator.T("qr.no_participants"))
 	}
 
 	fmt.Printf("✅ %s: %d\n\n", translator.T("qr.total_participants"), len(participants))
 
-	return prizes, participants, nil
+	return participants, nil
 }
 
-// loadFromExcel loads prizes and participants from Excel file
-func loadFromExcel(translator *i18n.Translator) ([]model.Prize, []model.Participant, error) {
-	fmt.Println(translator.T("data.source_excel"))
-
-	// Load configuration
-	dsCfg, err := config.LoadDataSourceConfig(".")
+// chooseCheckInHost picks the LAN address phones use to reach the check-in
+// server, asking the operator when the machine has several
+func chooseCheckInHost(translator *i18n.Translator) (string, error) {
+	addresses, err := checkin.LANAddresses()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+		return "", err
 	}
-
-	// Override type to excel if not set
-	if dsCfg.Type != "excel" {
-		dsCfg.Type = "excel"
-		if dsCfg.Excel.Path == "" {
-			dsCfg.Excel.Path = "lottery_template.xlsx"
-		}
+	switch len(addresses) {
+	case 0:
+		fmt.Printf("⚠️  %s\n", translator.T("qr.no_lan"))
+		return "", nil
+	case 1:
+		return addresses[0].IP.String(), nil
 	}
 
-	// Load prizes from Excel
-	prizes, err := datasource.LoadPrizesFromExcel(dsCfg.Excel.Path)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load prizes: %w", err)
+	choices := make([]string, len(addresses))
+	for i, a := range addresses {
+		choices[i] = a.String()
 	}
-
-	// Load participants from Excel
-	participants, err := datasource.LoadParticipantsFromExcel(dsCfg.Excel.Path)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load participants: %w", err)
-	}
-
-	return prizes, participants, nil
-}
-
-// loadFromQRCheckIn starts QR check-in server and collects participants
-// loadFromDatabase loads prizes and participants from database
-func loadFromDatabase(translator *i18n.Translator) ([]model.Prize, []model.Participant, error) {
-	fmt.Println(translator.T("data.source_db"))
-
-	// Load configuration
-	dsCfg, err := config.LoadDataSourceConfig(".")
+	i, quit, err := tui.SelectAddress(translator, choices)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+		return "", err
 	}
-
-	// Load prizes from config (YAML)
-	prizes, err := config.LoadPrizes(".")
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load prizes: %w", err)
-	}
-
-	// Load participants from database
-	participants, err := datasource.LoadParticipants(dsCfg)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load participants: %w", err)
+	if quit {
+		fmt.Println("Goodbye!")
+		return "", errQuit
 	}
-
-	return prizes, participants, nil
+	return addresses[i].IP.String(), nil
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/palemoky/lucky-day/internal/config"
+	"github.com/palemoky/lucky-day/internal/lottery"
+)
+
+// runSimulate implements the `simulate` subcommand: it runs the whole event many
+// times with the real engine and reports each participant's odds of winning each
+// prize, overall and grouped by winning history. It returns the process exit code.
+func runSimulate(args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	runs := fs.Int("runs", 1000, "number of simulated events")
+	seed := fs.Int64("seed", 1, "seed of the first simulated event; the same flags give the same results")
+	rosterPath := fs.String("roster", "", "participant roster, .xlsx or .csv (required)")
+	configDir := fs.String("config", ".", "directory containing config.yml with the weighting policy and column mapping")
+	prizesPath := fs.String("prizes", "", "prize config: .xlsx file or directory containing config.yml (default: the roster .xlsx, or the -config directory)")
+	eventYear := fs.Int("event-year", 0, "year the event belongs to (default: event_year in config.yml, or the current year)")
+	format := fs.String("format", "table", "output format: table or csv")
+	outPath := fs.String("out", "", "write the results to this file instead of stdout")
+	_ = fs.Parse(args) // ExitOnError handles parse failures
+
+	if *rosterPath == "" {
+		fmt.Fprintln(os.Stderr, "simulate: -roster is required")
+		fs.Usage()
+		return 2
+	}
+	if *format != "table" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "simulate: unknown format %q, want table or csv\n", *format)
+		return 2
+	}
+
+	participants, err := loadRoster(*rosterPath, *configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "simulate: failed to load roster: %v\n", err)
+		return 2
+	}
+
+	prizes, err := loadPrizeConfig(*prizesPath, *rosterPath, *configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "simulate: failed to load prizes: %v\n", err)
+		return 2
+	}
+
+	weights, err := loadWeightStrategy(*configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "simulate: failed to load weighting: %v\n", err)
+		return 2
+	}
+
+	if *eventYear == 0 {
+		if *eventYear, err = config.LoadEventYear(*configDir); err != nil {
+			fmt.Fprintf(os.Stderr, "simulate: failed to load event year: %v\n", err)
+			return 2
+		}
+	}
+
+	result, err := lottery.Simulate(participants, prizes, lottery.SimulateOptions{Runs: *runs, Seed: *seed, Weights: weights, EventYear: *eventYear})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "simulate: %v\n", err)
+		return 2
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "simulate: %v\n", err)
+			return 2
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+
+	if *format == "csv" {
+		err = writeSimulationCSV(out, result)
+	} else {
+		fmt.Fprintf(out, "Simulated %d events for %d with weighting %s\n\n", result.Runs, result.EventYear, weights)
+		err = writeSimulationTable(out, result)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "simulate: failed to write results: %v\n", err)
+		return 1
+	}
+	if *outPath != "" {
+		fmt.Printf("Simulated %d events, results written to %s\n", result.Runs, *outPath)
+	}
+	return 0
+}
+
+// historyLabel describes a winning-history bucket
+func historyLabel(bucket int) string {
+	switch {
+	case bucket == 0:
+		return "never won"
+	case bucket == 1:
+		return "won last year"
+	case bucket >= lottery.MaxHistoryBucket:
+		return fmt.Sprintf("won %d+ years ago", lottery.MaxHistoryBucket)
+	default:
+		return fmt.Sprintf("won %d years ago", bucket)
+	}
+}
+
+// writeSimulationTable prints the odds per history bucket, then per participant
+func writeSimulationTable(out io.Writer, result lottery.SimulationResult) error {
+	percent := func(p float64) string { return fmt.Sprintf("%.2f%%", p*100) }
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprint(tw, "History\tPeople")
+	for _, prize := range result.Prizes {
+		fmt.Fprintf(tw, "\t%s", prize.Name)
+	}
+	fmt.Fprintln(tw, "\tAny prize")
+	for _, b := range result.Buckets {
+		fmt.Fprintf(tw, "%s\t%d", historyLabel(b.Bucket), b.Participants)
+		for _, prize := range result.Prizes {
+			fmt.Fprintf(tw, "\t%s", percent(result.BucketProbability(b, prize.ID)))
+		}
+		fmt.Fprintf(tw, "\t%s\n", percent(result.BucketAnyProbability(b)))
+	}
+
+	fmt.Fprint(tw, "\nID\tName\tHistory")
+	for _, prize := range result.Prizes {
+		fmt.Fprintf(tw, "\t%s", prize.Name)
+	}
+	fmt.Fprintln(tw, "\tAny prize")
+	for _, o := range result.Participants {
+		fmt.Fprintf(tw, "%d\t%s\t%s", o.Participant.ID, o.Participant.Name, historyLabel(o.Bucket))
+		for _, prize := range result.Prizes {
+			fmt.Fprintf(tw, "\t%s", percent(result.Probability(o, prize.ID)))
+		}
+		fmt.Fprintf(tw, "\t%s\n", percent(result.AnyProbability(o)))
+	}
+	return tw.Flush()
+}
+
+// writeSimulationCSV writes one row per history bucket followed by one row per
+// participant; the Kind column tells them apart so the file can be filtered in a spreadsheet
+func writeSimulationCSV(out io.Writer, result lottery.SimulationResult) error {
+	prob := func(p float64) string { return strconv.FormatFloat(p, 'f', 6, 64) }
+	w := csv.NewWriter(out)
+
+	header := []string{"Kind", "ID", "Name", "History", "People"}
+	for _, prize := range result.Prizes {
+		header = append(header, prize.Name)
+	}
+	if err := w.Write(append(header, "Any prize")); err != nil {
+		return err
+	}
+
+	for _, b := range result.Buckets {
+		row := []string{"history", "", "", historyLabel(b.Bucket), strconv.Itoa(b.Participants)}
+		for _, prize := range result.Prizes {
+			row = append(row, prob(result.BucketProbability(b, prize.ID)))
+		}
+		if err := w.Write(append(row, prob(result.BucketAnyProbability(b)))); err != nil {
+			return err
+		}
+	}
+	for _, o := range result.Participants {
+		row := []string{"participant", strconv.Itoa(o.Participant.ID), o.Participant.Name, historyLabel(o.Bucket), "1"}
+		for _, prize := range result.Prizes {
+			row = append(row, prob(result.Probability(o, prize.ID)))
+		}
+		if err := w.Write(append(row, prob(result.AnyProbability(o)))); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
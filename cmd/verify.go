@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/palemoky/lucky-day/internal/config"
+	"github.com/palemoky/lucky-day/internal/datasource"
+	"github.com/palemoky/lucky-day/internal/lottery"
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// runVerify implements the `verify` subcommand: it replays every draw from the
+// revealed seed and reports pass/fail per prize. It returns the process exit code.
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	commitment := fs.String("commitment", "", "commitment published before the event (required)")
+	revealPath := fs.String("reveal", revealFile, "reveal file written after the event")
+	rosterPath := fs.String("roster", "", "participant roster, .xlsx or .csv (required)")
+	configDir := fs.String("config", ".", "directory containing config.yml with the weighting policy and column mapping")
+	prizesPath := fs.String("prizes", "", "prize config: .xlsx file or directory containing config.yml (default: the roster .xlsx, or the -config directory)")
+	_ = fs.Parse(args) // ExitOnError handles parse failures
+
+	if *commitment == "" || *rosterPath == "" {
+		fmt.Fprintln(os.Stderr, "verify: -commitment and -roster are required")
+		fs.Usage()
+		return 2
+	}
+
+	reveal, err := lottery.LoadReveal(*revealPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: %v\n", err)
+		return 2
+	}
+
+	participants, err := loadRoster(*rosterPath, *configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: failed to load roster: %v\n", err)
+		return 2
+	}
+
+	prizes, err := loadPrizeConfig(*prizesPath, *rosterPath, *configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: failed to load prizes: %v\n", err)
+		return 2
+	}
+
+	weights, err := loadWeightStrategy(*configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: failed to load weighting: %v\n", err)
+		return 2
+	}
+
+	report := lottery.Verify(participants, prizes, weights, reveal, strings.ToLower(strings.TrimSpace(*commitment)))
+
+	fmt.Println()
+	if report.CommitmentOK {
+		fmt.Printf("[PASS] commitment %s\n", report.Commitment)
+	} else {
+		fmt.Printf("[FAIL] commitment mismatch: published %s, recomputed %s\n", *commitment, report.Commitment)
+	}
+	for _, p := range report.Prizes {
+		if p.Passed {
+			fmt.Printf("[PASS] prize %d %s (%d draws)\n", p.PrizeID, p.PrizeName, p.Draws)
+		} else {
+			fmt.Printf("[FAIL] prize %d %s: %s\n", p.PrizeID, p.PrizeName, p.Reason)
+		}
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("[FAIL] %s\n", e)
+	}
+
+	if !report.Passed() {
+		fmt.Println("\nVerification FAILED")
+		return 1
+	}
+	fmt.Println("\nVerification PASSED")
+	return 0
+}
+
+// loadRoster loads participants from an Excel or CSV roster, reading its columns
+// with the mapping in config.yml under configDir
+func loadRoster(path, configDir string) ([]model.Participant, error) {
+	mapping, err := config.LoadMapping(configDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.DataSourceConfig{
+		Type:      "excel",
+		Excel:     config.ExcelConfig{Path: path},
+		Mapping:   mapping,
+		ConfigDir: configDir,
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		cfg.Type = "csv"
+		cfg.CSV = config.CSVConfig{Path: path}
+	}
+	return datasource.LoadParticipants(cfg)
+}
+
+// loadPrizeConfig loads prizes from an Excel Prizes sheet or a config.yml directory.
+// Without an explicit path it uses the roster workbook, or config.yml in configDir.
+func loadPrizeConfig(path, rosterPath, configDir string) ([]model.Prize, error) {
+	if path == "" {
+		if strings.EqualFold(filepath.Ext(rosterPath), ".xlsx") {
+			path = rosterPath
+		} else {
+			path = configDir
+		}
+	}
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		mapping, err := config.LoadMapping(configDir)
+		if err != nil {
+			return nil, err
+		}
+		src, err := datasource.Open(config.DataSourceConfig{Type: "excel", Excel: config.ExcelConfig{Path: path}, Mapping: mapping})
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = src.Close() }()
+		return src.LoadPrizes()
+	}
+	if strings.EqualFold(filepath.Ext(path), ".yml") {
+		path = filepath.Dir(path)
+	}
+	return config.LoadPrizes(path)
+}
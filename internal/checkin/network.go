@@ -0,0 +1,50 @@
+package checkin
+
+import (
+	"fmt"
+	"net"
+)
+
+// Address is a non-loopback IPv4 address of a network interface, which phones
+// on the same network can reach
+type Address struct {
+	Interface string
+	IP        net.IP
+}
+
+func (a Address) String() string {
+	return fmt.Sprintf("%s (%s)", a.IP, a.Interface)
+}
+
+// LANAddresses returns the addresses of the interfaces that are up, in the
+// order the system lists them. Loopback and link-local addresses are skipped,
+// since a QR code pointing at them only works on this machine.
+func LANAddresses() ([]Address, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+
+	var addresses []Address
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue // The interface may have gone away meanwhile
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if ok && reachable(ipNet.IP) {
+				addresses = append(addresses, Address{Interface: iface.Name, IP: ipNet.IP.To4()})
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// reachable reports whether ip can be reached from other devices on the LAN
+func reachable(ip net.IP) bool {
+	return ip.To4() != nil && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified() && !ip.IsMulticast()
+}
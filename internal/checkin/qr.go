@@ -0,0 +1,51 @@
+package checkin
+
+import (
+	"fmt"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// TerminalQRCode renders the QR code of content with Unicode half blocks, two
+// module rows per line. Light modules are drawn as blocks, so the code reads
+// correctly when printed light on a dark background. Modules are scaled up as
+// far as the code fits in width columns and height lines; when even the
+// smallest size does not fit, it is rendered unscaled and fits is false.
+func TerminalQRCode(content string, width, height int) (art string, fits bool, err error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	bits := q.Bitmap() // Includes the quiet zone
+	n := len(bits)
+
+	scale := min(width/n, 2*height/n)
+	fits = scale >= 1
+	scale = max(scale, 1)
+
+	size := n * scale
+	light := func(x, y int) bool {
+		return y < size && !bits[y/scale][x/scale]
+	}
+
+	var b strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := range size {
+			switch top, bottom := light(x, y), light(x, y+1); {
+			case top && bottom:
+				b.WriteRune('█')
+			case top:
+				b.WriteRune('▀')
+			case bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		if y+2 < size {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String(), fits, nil
+}
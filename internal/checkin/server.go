@@ -1,32 +1,55 @@
 package checkin
 
 import (
+	"crypto/rand"
 	"embed"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	qrcode "github.com/skip2/go-qrcode"
+	"github.com/xuri/excelize/v2"
 	"golang.org/x/time/rate"
 
 	"github.com/palemoky/lucky-day/internal/i18n"
 	"github.com/palemoky/lucky-day/internal/model"
 )
 
+const (
+	// participantsSheet is the roster sheet written by SaveToExcel
+	participantsSheet = "Participants"
+	// deviceCookie remembers the phone a person checked in from
+	deviceCookie = "lucky_day_device"
+)
+
 //go:embed templates/*.html
 var templatesFS embed.FS
 
 // Server represents the check-in server
 type Server struct {
 	port           int
+	bind           string // Listen address, empty for all interfaces
+	host           string // Host put in the check-in URL, localhost when unset
+	baseURL        string // Check-in URL overriding host and port, e.g. behind a reverse proxy
 	participants   []model.Participant
 	mu             sync.RWMutex
 	nextID         int
+	checkedIn      map[string]int            // ID of each check-in, keyed by checkInKey
+	devices        map[string]map[string]int // IDs checked in from each device cookie, keyed by normalized name
+	duplicates     int                       // Repeated submissions answered with the original ID
+	paused         bool                      // Check-in is paused and new submissions are rejected
+	roster         map[int]model.Participant // Participants allowed to check in by ID, nil to accept anyone
+	codes          map[string]int            // Roster ID by normalized check-in code, when checking in by code
 	server         *http.Server
 	translator     *i18n.Translator
 	newParticipant chan model.Participant
@@ -39,13 +62,52 @@ func NewServer(port int, translator *i18n.Translator) *Server {
 		port:           port,
 		participants:   make([]model.Participant, 0),
 		nextID:         1,
+		checkedIn:      make(map[string]int),
+		devices:        make(map[string]map[string]int),
 		translator:     translator,
 		newParticipant: make(chan model.Participant, 100),
 		limiter:        rate.NewLimiter(rate.Limit(10), 20), // 10 requests/sec, burst of 20
 	}
 }
 
-// Start starts the HTTP server
+// SetBind sets the address the server listens on; by default it listens on all interfaces
+func (s *Server) SetBind(bind string) {
+	s.bind = bind
+}
+
+// SetHost sets the host phones use to reach the server, usually one of LANAddresses
+func (s *Server) SetHost(host string) {
+	s.host = host
+}
+
+// SetBaseURL sets the check-in URL put in the QR code, overriding the host and
+// port. Use it when phones reach the server through a proxy or a DNS name.
+func (s *Server) SetBaseURL(baseURL string) {
+	s.baseURL = baseURL
+}
+
+// SetRoster makes the server only accept people on roster. They check in with
+// their ID and name or, when codeAttribute is set, with the personal code held
+// in that attribute, and the matching roster entry is marked present with its
+// ID and data. Call it before Start.
+func (s *Server) SetRoster(roster []model.Participant, codeAttribute string) {
+	s.roster = make(map[int]model.Participant, len(roster))
+	s.codes = nil
+	if codeAttribute != "" {
+		s.codes = make(map[string]int, len(roster))
+	}
+	for _, p := range roster {
+		s.roster[p.ID] = p
+		if s.codes != nil {
+			if code, ok := p.Attribute(codeAttribute); ok && code != "" {
+				s.codes[normalize(code)] = p.ID
+			}
+		}
+	}
+}
+
+// Start starts the HTTP server. It returns an error when the address cannot
+// be listened on, e.g. because the port is in use.
 func (s *Server) Start() error {
 	mux := http.NewServeMux()
 
@@ -62,13 +124,17 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/qr", s.handleQRCode)
 
 	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.port),
+		Addr:    net.JoinHostPort(s.bind, strconv.Itoa(s.port)),
 		Handler: mux,
 	}
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.server.Addr, err)
+	}
 
 	go func() {
 		// Start server silently to avoid screen flicker
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			// Only log errors, not normal startup
 			log.Printf("Server error: %v\n", err)
 		}
@@ -100,9 +166,12 @@ func (s *Server) handleCheckInPage(w http.ResponseWriter, r *http.Request) {
 
 	data := map[string]string{
 		"Lang":            lang,
+		"Mode":            s.mode(),
 		"Title":           s.getTranslation(lang, "qr.title"),
 		"NamePlaceholder": s.getTranslation(lang, "qr.name_placeholder"),
 		"DeptPlaceholder": s.getTranslation(lang, "qr.dept_placeholder"),
+		"IDPlaceholder":   s.getTranslation(lang, "qr.id_placeholder"),
+		"CodePlaceholder": s.getTranslation(lang, "qr.code_placeholder"),
 		"Submit":          s.getTranslation(lang, "qr.submit"),
 	}
 
@@ -130,29 +199,105 @@ func (s *Server) handleCheckIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Input validation
-	name := strings.TrimSpace(r.FormValue("name"))
-	if name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
-		return
+	var (
+		participant model.Participant
+		key         string
+	)
+	if s.roster != nil {
+		p, err := s.matchRoster(r.FormValue("id"), r.FormValue("name"), r.FormValue("code"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		participant = p
+		key = "id:" + strconv.Itoa(p.ID)
+	} else {
+		// Input validation
+		name := strings.TrimSpace(r.FormValue("name"))
+		if name == "" {
+			http.Error(w, "Name is required", http.StatusBadRequest)
+			return
+		}
+
+		// Validate name length (1-100 characters)
+		if len(name) > 100 {
+			http.Error(w, "Name is too long (max 100 characters)", http.StatusBadRequest)
+			return
+		}
+
+		department := strings.TrimSpace(r.FormValue("department"))
+		if len(department) > 100 {
+			http.Error(w, "Department is too long (max 100 characters)", http.StatusBadRequest)
+			return
+		}
+		participant = model.Participant{
+			Name:           name,
+			Department:     department,
+			WinningHistory: []model.WinningRecord{},
+		}
+		key = checkInKey(name, department)
 	}
 
-	// Validate name length (1-100 characters)
-	if len(name) > 100 {
-		http.Error(w, "Name is too long (max 100 characters)", http.StatusBadRequest)
-		return
+	// Without a roster anyone can type a department, so a person resubmitting from
+	// the same phone is recognized by the cookie and the name even if the department
+	// is typed differently. Others checking in on a shared phone are new people.
+	var device string
+	if s.roster == nil {
+		if cookie, err := r.Cookie(deviceCookie); err == nil && len(cookie.Value) <= 64 {
+			device = cookie.Value
+		}
 	}
 
 	s.mu.Lock()
-	participant := model.Participant{
-		ID:             s.nextID,
-		Name:           name,
-		WinningHistory: []model.WinningRecord{},
+	id, ok := s.checkedIn[key]
+	if !ok && device != "" {
+		id, ok = s.devices[device][normalize(participant.Name)]
+	}
+	if ok {
+		// Repeated submissions, e.g. a double tap or a refresh, get the original ID
+		s.duplicates++
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"already": true,
+			"message": "Already checked in",
+			"id":      id,
+			"name":    participant.Name,
+		}) // Ignore encoding error
+		return
+	}
+	if s.paused {
+		s.mu.Unlock()
+		http.Error(w, "Check-in is paused", http.StatusServiceUnavailable)
+		return
+	}
+	if s.roster == nil {
+		participant.ID = s.nextID
+		s.nextID++
+		if device == "" {
+			device = newDeviceToken()
+		}
+		if s.devices[device] == nil {
+			s.devices[device] = make(map[string]int)
+		}
+		s.devices[device][normalize(participant.Name)] = participant.ID
 	}
 	s.participants = append(s.participants, participant)
-	s.nextID++
+	s.checkedIn[key] = participant.ID
 	s.mu.Unlock()
 
+	if device != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     deviceCookie,
+			Value:    device,
+			Path:     "/",
+			MaxAge:   int((24 * time.Hour).Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
 	// Notify via channel
 	select {
 	case s.newParticipant <- participant:
@@ -169,6 +314,56 @@ func (s *Server) handleCheckIn(w http.ResponseWriter, r *http.Request) {
 	}) // Ignore encoding error
 }
 
+// newDeviceToken returns a random token identifying a phone
+func newDeviceToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b) // Never fails on supported platforms
+	return hex.EncodeToString(b)
+}
+
+// mode returns how people check in: "code", "roster" (ID and name), or "" for free text
+func (s *Server) mode() string {
+	switch {
+	case s.codes != nil:
+		return "code"
+	case s.roster != nil:
+		return "roster"
+	default:
+		return ""
+	}
+}
+
+// matchRoster finds the roster entry of a submission, by code when checking in
+// by code, otherwise by ID with a matching name
+func (s *Server) matchRoster(id, name, code string) (model.Participant, error) {
+	if s.codes != nil {
+		if rosterID, ok := s.codes[normalize(code)]; ok && strings.TrimSpace(code) != "" {
+			return s.roster[rosterID], nil
+		}
+		return model.Participant{}, errors.New("check-in code not found")
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(id))
+	if err != nil {
+		return model.Participant{}, errors.New("invalid ID")
+	}
+	p, ok := s.roster[n]
+	if !ok || normalize(p.Name) != normalize(name) {
+		return model.Participant{}, errors.New("ID and name do not match the roster")
+	}
+	return p, nil
+}
+
+// normalize lowers the case and collapses the spacing of s for comparison
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// checkInKey identifies a person by name and department, ignoring case and spacing
+func checkInKey(name, department string) string {
+	return normalize(name) + "\x00" + normalize(department)
+}
+
 // handleCount returns the current participant count
 func (s *Server) handleCount(w http.ResponseWriter, r *http.Request) {
 	s.mu.RLock()
@@ -183,9 +378,7 @@ func (s *Server) handleCount(w http.ResponseWriter, r *http.Request) {
 
 // handleQRCode serves the QR code image
 func (s *Server) handleQRCode(w http.ResponseWriter, r *http.Request) {
-	url := fmt.Sprintf("http://localhost:%d/?lang=%s", s.port, s.translator.GetLanguage())
-
-	png, err := qrcode.Encode(url, qrcode.Medium, 256)
+	png, err := qrcode.Encode(s.GetURL(), qrcode.Medium, 256)
 	if err != nil {
 		http.Error(w, "Failed to generate QR code", http.StatusInternalServerError)
 		return
@@ -213,6 +406,27 @@ func (s *Server) GetParticipantCount() int {
 	return len(s.participants)
 }
 
+// GetDuplicateCount returns the number of repeated submissions by people who had already checked in
+func (s *Server) GetDuplicateCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.duplicates
+}
+
+// SetPaused pauses or resumes check-in; while paused, submissions are rejected
+func (s *Server) SetPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = paused
+}
+
+// Paused reports whether check-in is paused
+func (s *Server) Paused() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.paused
+}
+
 // GetNewParticipantChannel returns the channel for new participant notifications
 func (s *Server) GetNewParticipantChannel() <-chan model.Participant {
 	return s.newParticipant
@@ -223,25 +437,64 @@ func GenerateQRCode(url, outputPath string) error {
 	return qrcode.WriteFile(url, qrcode.Medium, 256, outputPath)
 }
 
-// getTranslation is a helper to get translations
+// getTranslation is a helper to get translations. It leaves the shared
+// translator alone, which the terminal and the QR code URL keep using.
 func (s *Server) getTranslation(lang, key string) string {
 	if lang == "en" {
-		s.translator.SetLanguage(i18n.English)
-	} else {
-		s.translator.SetLanguage(i18n.Chinese)
+		return i18n.NewTranslator(i18n.English).T(key)
 	}
-	return s.translator.T(key)
+	return i18n.NewTranslator(i18n.Chinese).T(key)
 }
 
-// GetURL returns the check-in URL
+// GetURL returns the check-in URL encoded in the QR code: the base URL when
+// set, otherwise the host and port, with the current language appended
 func (s *Server) GetURL() string {
-	return fmt.Sprintf("http://localhost:%d/?lang=%s", s.port, s.translator.GetLanguage())
+	lang := string(s.translator.GetLanguage())
+	if s.baseURL != "" {
+		if u, err := url.Parse(s.baseURL); err == nil {
+			q := u.Query()
+			if !q.Has("lang") {
+				q.Set("lang", lang)
+				u.RawQuery = q.Encode()
+			}
+			if u.Path == "" {
+				u.Path = "/"
+			}
+			return u.String()
+		}
+		return s.baseURL
+	}
+
+	host := s.host
+	if host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("http://%s/?lang=%s", net.JoinHostPort(host, strconv.Itoa(s.port)), lang)
 }
 
-// SaveToExcel saves checked-in participants to Excel file
+// SaveToExcel saves the checked-in participants to the Participants sheet of
+// an Excel file, laid out like the roster template so it can be loaded back
 func (s *Server) SaveToExcel(filePath string) error {
-	// This will be implemented using the datasource.SaveParticipantsToExcel function
-	// For now, just return nil
+	participants := s.GetParticipants()
+
+	f := excelize.NewFile()
+	defer func() { _ = f.Close() }()
+	if err := f.SetSheetName("Sheet1", participantsSheet); err != nil {
+		return fmt.Errorf("failed to create %s sheet: %w", participantsSheet, err)
+	}
+	rows := [][]interface{}{{"ID", "Name", "Department"}}
+	for _, p := range participants {
+		rows = append(rows, []interface{}{p.ID, p.Name, p.Department})
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(participantsSheet, cell, &row); err != nil {
+			return fmt.Errorf("failed to write row %d: %w", i+1, err)
+		}
+	}
+	if err := f.SaveAs(filePath); err != nil {
+		return fmt.Errorf("failed to save %s: %w", filePath, err)
+	}
 	return nil
 }
 
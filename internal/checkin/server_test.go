@@ -2,13 +2,20 @@ package checkin
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/time/rate"
+
 	"github.com/palemoky/lucky-day/internal/i18n"
 	"github.com/palemoky/lucky-day/internal/model"
 )
@@ -78,6 +85,28 @@ func TestHandleCheckIn_Success(t *testing.T) {
 	}
 }
 
+func TestHandleCheckIn_Department(t *testing.T) {
+	translator := i18n.NewTranslator(i18n.Chinese)
+	server := NewServer(8888, translator)
+
+	form := url.Values{}
+	form.Add("name", "张三")
+	form.Add("department", " 研发部 ")
+
+	req := httptest.NewRequest(http.MethodPost, "/checkin", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	server.handleCheckIn(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if dept := server.GetParticipants()[0].Department; dept != "研发部" {
+		t.Errorf("Expected department '研发部', got '%s'", dept)
+	}
+}
+
 func TestHandleCheckIn_EmptyName(t *testing.T) {
 	translator := i18n.NewTranslator(i18n.Chinese)
 	server := NewServer(8888, translator)
@@ -280,6 +309,74 @@ func TestGetURL(t *testing.T) {
 	}
 }
 
+func TestGetURL_Host(t *testing.T) {
+	translator := i18n.NewTranslator(i18n.Chinese)
+	server := NewServer(9000, translator)
+	server.SetHost("192.168.1.20")
+
+	if url, expected := server.GetURL(), "http://192.168.1.20:9000/?lang=zh"; url != expected {
+		t.Errorf("Expected URL '%s', got '%s'", expected, url)
+	}
+}
+
+func TestGetURL_BaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{"https://lottery.example.com", "https://lottery.example.com/?lang=zh"},
+		{"https://example.com/checkin/", "https://example.com/checkin/?lang=zh"},
+		{"http://10.0.0.5:8080/?lang=en", "http://10.0.0.5:8080/?lang=en"},
+	}
+
+	for _, tt := range tests {
+		translator := i18n.NewTranslator(i18n.Chinese)
+		server := NewServer(8888, translator)
+		server.SetHost("192.168.1.20") // The base URL takes precedence
+		server.SetBaseURL(tt.baseURL)
+
+		if url := server.GetURL(); url != tt.expected {
+			t.Errorf("Expected URL '%s', got '%s'", tt.expected, url)
+		}
+	}
+}
+
+func TestStart_PortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	server := NewServer(listener.Addr().(*net.TCPAddr).Port, i18n.NewTranslator(i18n.Chinese))
+	server.SetBind("127.0.0.1")
+	if err := server.Start(); err == nil {
+		_ = server.Stop()
+		t.Error("Expected an error when the port is in use")
+	}
+}
+
+func TestReachable(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{"192.168.1.20", true},
+		{"10.0.0.5", true},
+		{"127.0.0.1", false},
+		{"169.254.10.1", false},
+		{"0.0.0.0", false},
+		{"fe80::1", false},
+		{"2001:db8::1", false},
+	}
+
+	for _, tt := range tests {
+		if got := reachable(net.ParseIP(tt.ip)); got != tt.expected {
+			t.Errorf("reachable(%s) = %v, expected %v", tt.ip, got, tt.expected)
+		}
+	}
+}
+
 func TestGetNewParticipantChannel(t *testing.T) {
 	translator := i18n.NewTranslator(i18n.Chinese)
 	server := NewServer(8888, translator)
@@ -373,3 +470,314 @@ func TestWaitForParticipants(t *testing.T) {
 		t.Errorf("Expected to wait at least 100ms, waited %v", elapsed)
 	}
 }
+
+func TestTerminalQRCode(t *testing.T) {
+	url := "http://192.168.1.20:8888/?lang=zh"
+
+	small, fits, err := TerminalQRCode(url, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to render QR code: %v", err)
+	}
+	if fits {
+		t.Error("Expected the QR code not to fit in an empty terminal")
+	}
+	lines := strings.Split(small, "\n")
+	size := len([]rune(lines[0]))
+	if len(lines) != (size+1)/2 {
+		t.Errorf("Expected %d lines for %d modules, got %d", (size+1)/2, size, len(lines))
+	}
+	// The quiet zone is light, so the first line is all full blocks
+	if lines[0] != strings.Repeat("█", size) {
+		t.Errorf("Expected the first line to be the quiet zone, got %q", lines[0])
+	}
+
+	large, fits, err := TerminalQRCode(url, 2*size, size)
+	if err != nil {
+		t.Fatalf("Failed to render QR code: %v", err)
+	}
+	if !fits {
+		t.Error("Expected the QR code to fit")
+	}
+	if width := len([]rune(strings.Split(large, "\n")[0])); width != 2*size {
+		t.Errorf("Expected the QR code to be scaled to %d columns, got %d", 2*size, width)
+	}
+}
+
+// postCheckIn submits the check-in form and returns the recorded response
+func postCheckIn(server *Server, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/checkin", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	server.handleCheckIn(w, req)
+	return w
+}
+
+// decodeCheckIn returns the ID of a successful check-in response and whether
+// the person had already checked in
+func decodeCheckIn(t *testing.T, w *httptest.ResponseRecorder) (int, bool) {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response struct {
+		Success bool `json:"success"`
+		Already bool `json:"already"`
+		ID      int  `json:"id"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !response.Success {
+		t.Error("Expected success to be true")
+	}
+	return response.ID, response.Already
+}
+
+func TestHandleCheckIn_Duplicate(t *testing.T) {
+	server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+
+	if w := postCheckIn(server, url.Values{"name": {"张三"}, "department": {"研发部"}}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	// The same person with different case and spacing gets the original ID
+	if id, already := decodeCheckIn(t, postCheckIn(server, url.Values{"name": {" 张三 "}, "department": {"研发部"}})); !already || id != 1 {
+		t.Errorf("Expected already checked in as 1, got %d (already %v)", id, already)
+	}
+	// Same name in another department is a different person
+	if w := postCheckIn(server, url.Values{"name": {"张三"}, "department": {"市场部"}}); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	if count := server.GetParticipantCount(); count != 2 {
+		t.Errorf("Expected 2 participants, got %d", count)
+	}
+	if dup := server.GetDuplicateCount(); dup != 1 {
+		t.Errorf("Expected 1 duplicate, got %d", dup)
+	}
+}
+
+func TestHandleCheckIn_Paused(t *testing.T) {
+	server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+
+	server.SetPaused(true)
+	if !server.Paused() {
+		t.Fatal("Expected the server to be paused")
+	}
+	if w := postCheckIn(server, url.Values{"name": {"张三"}}); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+
+	server.SetPaused(false)
+	if w := postCheckIn(server, url.Values{"name": {"张三"}}); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if count := server.GetParticipantCount(); count != 1 {
+		t.Errorf("Expected 1 participant, got %d", count)
+	}
+}
+
+func TestSaveToExcel(t *testing.T) {
+	server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+	postCheckIn(server, url.Values{"name": {"张三"}, "department": {"研发部"}})
+	postCheckIn(server, url.Values{"name": {"李四"}})
+
+	path := filepath.Join(t.TempDir(), "checkin.xlsx")
+	if err := server.SaveToExcel(path); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	rows, err := f.GetRows(participantsSheet)
+	if err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+	expected := [][]string{{"ID", "Name", "Department"}, {"1", "张三", "研发部"}, {"2", "李四"}}
+	if fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("Expected rows %v, got %v", expected, rows)
+	}
+}
+
+func TestHandleCheckIn_Roster(t *testing.T) {
+	server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+	server.SetRoster([]model.Participant{
+		{ID: 1001, Name: "张三", Department: "研发部"},
+		{ID: 1002, Name: "李四"},
+	}, "")
+
+	// Someone not on the roster, or with the wrong name, is rejected
+	for _, form := range []url.Values{
+		{"id": {"9999"}, "name": {"王五"}},
+		{"id": {"1001"}, "name": {"李四"}},
+		{"id": {"abc"}, "name": {"张三"}},
+	} {
+		if w := postCheckIn(server, form); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 for %v, got %d", form, w.Code)
+		}
+	}
+
+	if w := postCheckIn(server, url.Values{"id": {" 1001 "}, "name": {"张三"}}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if id, already := decodeCheckIn(t, postCheckIn(server, url.Values{"id": {"1001"}, "name": {"张三"}})); !already || id != 1001 {
+		t.Errorf("Expected already checked in as 1001, got %d (already %v)", id, already)
+	}
+
+	// The roster entry is kept with its ID and department
+	participants := server.GetParticipants()
+	if len(participants) != 1 {
+		t.Fatalf("Expected 1 participant, got %d", len(participants))
+	}
+	if participants[0].ID != 1001 || participants[0].Department != "研发部" {
+		t.Errorf("Expected roster entry 1001 of 研发部, got %+v", participants[0])
+	}
+}
+
+func TestHandleCheckIn_RosterCode(t *testing.T) {
+	server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+	server.SetRoster([]model.Participant{
+		{ID: 1001, Name: "张三", Attributes: map[string]string{"签到码": "AB12"}},
+		{ID: 1002, Name: "李四"},
+	}, "签到码")
+
+	if w := postCheckIn(server, url.Values{"code": {""}}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for an empty code, got %d", w.Code)
+	}
+	// ID and name are not accepted when checking in by code
+	if w := postCheckIn(server, url.Values{"id": {"1002"}, "name": {"李四"}}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without a code, got %d", w.Code)
+	}
+	if w := postCheckIn(server, url.Values{"code": {"ab12"}}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	participants := server.GetParticipants()
+	if len(participants) != 1 || participants[0].ID != 1001 {
+		t.Errorf("Expected roster entry 1001, got %+v", participants)
+	}
+}
+
+func TestHandleCheckInPage_Mode(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(s *Server)
+		expected []string
+	}{
+		{name: "free text", setup: func(s *Server) {}, expected: []string{`name="name"`, `name="department"`}},
+		{name: "roster", setup: func(s *Server) { s.SetRoster(nil, "") }, expected: []string{`name="id"`, `name="name"`}},
+		{name: "code", setup: func(s *Server) { s.SetRoster(nil, "code") }, expected: []string{`name="code"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+			tt.setup(server)
+
+			w := httptest.NewRecorder()
+			server.handleCheckInPage(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			body := w.Body.String()
+			for _, want := range tt.expected {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected page to contain %s", want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleCheckIn_DeviceCookie(t *testing.T) {
+	server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+
+	w := postCheckIn(server, url.Values{"name": {"张三"}, "department": {"研发部"}})
+	var device *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == deviceCookie {
+			device = cookie
+		}
+	}
+	if device == nil || device.Value == "" {
+		t.Fatal("Expected a device cookie")
+	}
+
+	// The same person resubmitting from the phone with another department gets the original ID
+	if id, already := decodeCheckIn(t, postCheckIn(server, url.Values{"name": {"张三"}, "department": {"研发"}}, device)); !already || id != 1 {
+		t.Errorf("Expected already checked in as 1, got %d (already %v)", id, already)
+	}
+	// On another phone the same name with another department is a different person
+	if id, already := decodeCheckIn(t, postCheckIn(server, url.Values{"name": {"张三"}, "department": {"市场部"}})); already || id != 2 {
+		t.Errorf("Expected new check-in as 2, got %d (already %v)", id, already)
+	}
+
+	if count := server.GetParticipantCount(); count != 2 {
+		t.Errorf("Expected 2 participants, got %d", count)
+	}
+}
+
+func TestHandleCheckIn_SharedDevice(t *testing.T) {
+	server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+
+	w := postCheckIn(server, url.Values{"name": {"张三"}})
+	cookies := w.Result().Cookies()
+
+	// A colleague checking in on the same phone is registered as a new person
+	if id, already := decodeCheckIn(t, postCheckIn(server, url.Values{"name": {"李四"}}, cookies...)); already || id != 2 {
+		t.Errorf("Expected new check-in as 2, got %d (already %v)", id, already)
+	}
+	// Both are recognized when they resubmit from that phone
+	for name, want := range map[string]int{"张三": 1, "李四": 2} {
+		if id, already := decodeCheckIn(t, postCheckIn(server, url.Values{"name": {name}, "department": {"研发部"}}, cookies...)); !already || id != want {
+			t.Errorf("Expected %s already checked in as %d, got %d (already %v)", name, want, id, already)
+		}
+	}
+
+	if count := server.GetParticipantCount(); count != 2 {
+		t.Errorf("Expected 2 participants, got %d", count)
+	}
+}
+
+func TestHandleCheckIn_ConcurrentDuplicates(t *testing.T) {
+	server := NewServer(8888, i18n.NewTranslator(i18n.Chinese))
+	server.limiter = rate.NewLimiter(rate.Inf, 0)
+
+	const submissions = 50
+	ids := make(chan int, submissions)
+	var wg sync.WaitGroup
+	for i := 0; i < submissions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := postCheckIn(server, url.Values{"name": {"张三"}, "department": {"研发部"}})
+			var response struct {
+				ID int `json:"id"`
+			}
+			if w.Code == http.StatusOK && json.NewDecoder(w.Body).Decode(&response) == nil {
+				ids <- response.ID
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	received := 0
+	for id := range ids {
+		received++
+		if id != 1 {
+			t.Errorf("Expected every submission to get ID 1, got %d", id)
+		}
+	}
+	if received != submissions {
+		t.Errorf("Expected %d successful responses, got %d", submissions, received)
+	}
+	if count := server.GetParticipantCount(); count != 1 {
+		t.Errorf("Expected 1 participant, got %d", count)
+	}
+	if dup := server.GetDuplicateCount(); dup != submissions-1 {
+		t.Errorf("Expected %d duplicates, got %d", submissions-1, dup)
+	}
+}
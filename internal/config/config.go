@@ -13,10 +13,29 @@ type DataSourceConfig struct {
 	CSV      CSVConfig      `mapstructure:"csv"`
 	Excel    ExcelConfig    `mapstructure:"excel"`
 	Database DatabaseConfig `mapstructure:"database"`
+
+	Mapping   MappingConfig `mapstructure:"-"` // 对应 config.yml 顶层的 mapping
+	ConfigDir string        `mapstructure:"-"` // config.yml 所在的目录，没有奖品表的数据源从这里读取奖品
+}
+
+// MappingConfig 导入 Excel、CSV 名单时使用的工作表名称和表头映射
+type MappingConfig struct {
+	Sheets       SheetsConfig      `mapstructure:"sheets"`
+	Prizes       map[string]string `mapstructure:"prizes"`       // 奖品字段 → 表头，如 count: "数量"
+	Participants map[string]string `mapstructure:"participants"` // 参与者字段 → 表头，如 id: "Employee No."
+}
+
+// SheetsConfig Excel 中各工作表的名称，留空时使用默认名称
+type SheetsConfig struct {
+	Prizes       string `mapstructure:"prizes"`
+	Participants string `mapstructure:"participants"`
+	Winners      string `mapstructure:"winners"`
 }
 
 type CSVConfig struct {
-	Path string `mapstructure:"path"`
+	Path    string `mapstructure:"path"`
+	Winners string `mapstructure:"winners"` // 中奖结果保存的 CSV 文件，默认为名单所在目录的 lottery_winners.csv
+	History string `mapstructure:"history"` // 往年中奖记录的 CSV 文件，默认读取 Winners 文件
 }
 
 type ExcelConfig struct {
@@ -28,41 +47,143 @@ type DatabaseConfig struct {
 	DSN    string `mapstructure:"dsn"`
 }
 
+// newViper 创建只读取 path 下 config.yml 的 viper 实例。每次加载使用独立的实例，
+// 避免全局 viper 累积的搜索路径让后续加载读到其他目录的配置
+func newViper(path string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("yml")
+	v.AddConfigPath(path)
+	return v
+}
+
 // LoadDataSourceConfig 加载数据源配置
 func LoadDataSourceConfig(path string) (DataSourceConfig, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yml")
-	viper.AddConfigPath(path)
+	v := newViper(path)
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return DataSourceConfig{}, fmt.Errorf("无法读取配置文件: %w", err)
 	}
 
 	var config DataSourceConfig
-	if err := viper.UnmarshalKey("datasource", &config); err != nil {
+	if err := v.UnmarshalKey("datasource", &config); err != nil {
 		return DataSourceConfig{}, fmt.Errorf("解析 datasource 配置失败: %w", err)
 	}
+	if err := v.UnmarshalKey("mapping", &config.Mapping); err != nil {
+		return DataSourceConfig{}, fmt.Errorf("解析 mapping 配置失败: %w", err)
+	}
+	config.ConfigDir = path
 	return config, nil
 }
 
+// LoadMapping 加载导入名单时的工作表名称和表头映射；未配置时返回零值，表示使用默认的表头
+func LoadMapping(path string) (MappingConfig, error) {
+	v := newViper(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return MappingConfig{}, fmt.Errorf("无法读取配置文件: %w", err)
+		}
+	}
+
+	var mapping MappingConfig
+	if err := v.UnmarshalKey("mapping", &mapping); err != nil {
+		return MappingConfig{}, fmt.Errorf("解析 mapping 配置失败: %w", err)
+	}
+	return mapping, nil
+}
+
 // LoadPrizes
 func LoadPrizes(path string) ([]model.Prize, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yml")
-	viper.AddConfigPath(path)
+	v := newViper(path)
 
-	// 确保 viper 已经读取过配置
-	if err := viper.ReadInConfig(); err != nil {
-		// 如果文件不存在，可以忽略，因为可能已经被 LoadDataSourceConfig 读取过了
+	if err := v.ReadInConfig(); err != nil {
+		// 配置文件不存在时没有奖品
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, fmt.Errorf("fatal error config file: %s", err)
 		}
 	}
 
 	var prizes []model.Prize
-	if err := viper.UnmarshalKey("prizes", &prizes); err != nil {
+	if err := v.UnmarshalKey("prizes", &prizes); err != nil {
 		return nil, fmt.Errorf("unable to decode into struct, %v", err)
 	}
 
 	return prizes, nil
 }
+
+// DefaultCheckInPort 签到服务默认监听的端口
+const DefaultCheckInPort = 8888
+
+// CheckInConfig 二维码签到服务的配置
+type CheckInConfig struct {
+	Bind    string `mapstructure:"bind"`     // 监听地址，留空时监听全部网卡
+	Host    string `mapstructure:"host"`     // 二维码中的主机名或 IP，留空时自动检测局域网地址
+	Port    int    `mapstructure:"port"`     // 监听端口，默认 8888
+	BaseURL string `mapstructure:"base_url"` // 二维码中的完整地址，配置后忽略 host 和 port，用于反向代理等场景
+	Roster  bool   `mapstructure:"roster"`   // 按数据源的名单核对签到，只有签到的人参与抽奖
+	Code    string `mapstructure:"code"`     // 名单中签到码所在的列，配置后凭签到码签到，代替工号和姓名
+}
+
+// LoadCheckIn 加载二维码签到服务的配置，未配置时返回默认端口
+func LoadCheckIn(path string) (CheckInConfig, error) {
+	v := newViper(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return CheckInConfig{}, fmt.Errorf("无法读取配置文件: %w", err)
+		}
+	}
+
+	var cfg CheckInConfig
+	if err := v.UnmarshalKey("checkin", &cfg); err != nil {
+		return CheckInConfig{}, fmt.Errorf("解析 checkin 配置失败: %w", err)
+	}
+	if cfg.Port == 0 {
+		cfg.Port = DefaultCheckInPort
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return CheckInConfig{}, fmt.Errorf("checkin.port 配置无效: %d", cfg.Port)
+	}
+	return cfg, nil
+}
+
+// WeightStrategyConfig 权重策略配置，对应 lottery 中的内置策略
+type WeightStrategyConfig struct {
+	Strategy string             `mapstructure:"strategy"`
+	Params   map[string]float64 `mapstructure:"params"`
+}
+
+// LoadWeighting 加载权重策略配置，多个策略的权重相乘；未配置时返回空列表
+func LoadWeighting(path string) ([]WeightStrategyConfig, error) {
+	v := newViper(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return nil, fmt.Errorf("无法读取配置文件: %w", err)
+		}
+	}
+
+	var weighting []WeightStrategyConfig
+	if err := v.UnmarshalKey("weighting", &weighting); err != nil {
+		return nil, fmt.Errorf("解析 weighting 配置失败: %w", err)
+	}
+	return weighting, nil
+}
+
+// LoadEventYear 加载活动所属的年份，用于计算往年中奖的衰减权重；未配置时返回 0，表示使用当前年份
+func LoadEventYear(path string) (int, error) {
+	v := newViper(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return 0, fmt.Errorf("无法读取配置文件: %w", err)
+		}
+	}
+
+	year := v.GetInt("event_year")
+	if year < 0 {
+		return 0, fmt.Errorf("event_year 配置无效: %d", year)
+	}
+	return year, nil
+}
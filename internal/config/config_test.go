@@ -34,6 +34,7 @@ prizes:
     count: 3
     level: 1
     probability: 0.2
+    batch_size: 2
 `
 				err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte(configContent), 0o644)
 				require.NoError(t, err)
@@ -56,6 +57,8 @@ prizes:
 				assert.Equal(t, 3, prizes[1].Count)
 				assert.Equal(t, model.PrizeLevel(1), prizes[1].Level)
 				assert.Equal(t, 0.2, prizes[1].Probability)
+				assert.Equal(t, 2, prizes[1].BatchSize)
+				assert.Zero(t, prizes[0].BatchSize, "未配置每批人数时一次抽完")
 			},
 		},
 		{
@@ -94,6 +97,34 @@ prizes:
 	}
 }
 
+func TestLoadPrizes_SeparateDirectories(t *testing.T) {
+	writeConfig := func(t *testing.T, name string) string {
+		dir := t.TempDir()
+		content := "prizes:\n  - id: 1\n    name: " + name + "\n    count: 1\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(content), 0o644))
+		return dir
+	}
+	first := writeConfig(t, "第一个目录的奖品")
+	second := writeConfig(t, "第二个目录的奖品")
+
+	// 先加载第一个目录的其他配置，不能影响随后从第二个目录加载的奖品
+	_, err := LoadMapping(first)
+	require.NoError(t, err)
+	prizes, err := LoadPrizes(first)
+	require.NoError(t, err)
+	require.Len(t, prizes, 1)
+	assert.Equal(t, "第一个目录的奖品", prizes[0].Name)
+
+	prizes, err = LoadPrizes(second)
+	require.NoError(t, err)
+	require.Len(t, prizes, 1)
+	assert.Equal(t, "第二个目录的奖品", prizes[0].Name)
+
+	prizes, err = LoadPrizes(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, prizes, "目录中没有配置文件时没有奖品")
+}
+
 func TestLoadDataSourceConfig(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -191,3 +222,160 @@ datasource:
 		})
 	}
 }
+
+func TestLoadWeighting(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantErr  bool
+		validate func(t *testing.T, cfgs []WeightStrategyConfig)
+	}{
+		{
+			name: "组合策略",
+			content: `
+weighting:
+  - strategy: history_decay
+    params:
+      decay_factor: 0.8
+      level_penalty_factor: 2
+  - strategy: tenure_bonus
+    params:
+      bonus_per_year: 0.1
+`,
+			validate: func(t *testing.T, cfgs []WeightStrategyConfig) {
+				require.Len(t, cfgs, 2)
+				assert.Equal(t, "history_decay", cfgs[0].Strategy)
+				assert.Equal(t, 0.8, cfgs[0].Params["decay_factor"])
+				assert.Equal(t, 2.0, cfgs[0].Params["level_penalty_factor"])
+				assert.Equal(t, "tenure_bonus", cfgs[1].Strategy)
+				assert.Equal(t, 0.1, cfgs[1].Params["bonus_per_year"])
+			},
+		},
+		{
+			name:    "未配置权重策略",
+			content: `prizes: []`,
+			validate: func(t *testing.T, cfgs []WeightStrategyConfig) {
+				assert.Empty(t, cfgs)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(tt.content), 0o644))
+
+			cfgs, err := LoadWeighting(dir)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.validate(t, cfgs)
+		})
+	}
+}
+
+func TestLoadEventYear(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+		wantErr  bool
+	}{
+		{name: "配置活动年份", content: "event_year: 2025\n", expected: 2025},
+		{name: "未配置活动年份", content: "prizes: []\n", expected: 0},
+		{name: "年份无效", content: "event_year: -1\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(tt.content), 0o644))
+
+			year, err := LoadEventYear(dir)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, year)
+		})
+	}
+}
+
+func TestLoadMapping(t *testing.T) {
+	dir := t.TempDir()
+	content := `mapping:
+  sheets:
+    participants: 员工名单
+  participants:
+    id: "Employee No."
+    name: 姓名
+    department: 部门
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(content), 0o644))
+
+	mapping, err := LoadMapping(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "员工名单", mapping.Sheets.Participants)
+	assert.Empty(t, mapping.Sheets.Prizes, "未配置的工作表使用默认名称")
+	assert.Equal(t, map[string]string{"id": "Employee No.", "name": "姓名", "department": "部门"}, mapping.Participants)
+
+	cfg, err := LoadDataSourceConfig(dir)
+	require.NoError(t, err)
+	assert.Equal(t, mapping, cfg.Mapping)
+}
+
+func TestLoadCheckIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected CheckInConfig
+		wantErr  bool
+	}{
+		{
+			name:     "未配置时使用默认端口",
+			content:  "event_year: 2025\n",
+			expected: CheckInConfig{Port: DefaultCheckInPort},
+		},
+		{
+			name: "指定地址和端口",
+			content: `checkin:
+  bind: 0.0.0.0
+  host: 192.168.1.20
+  port: 9000
+  base_url: "https://lottery.example.com"
+`,
+			expected: CheckInConfig{Bind: "0.0.0.0", Host: "192.168.1.20", Port: 9000, BaseURL: "https://lottery.example.com"},
+		},
+		{
+			name: "按名单核对签到",
+			content: `checkin:
+  roster: true
+  code: 签到码
+`,
+			expected: CheckInConfig{Port: DefaultCheckInPort, Roster: true, Code: "签到码"},
+		},
+		{
+			name:    "端口无效",
+			content: "checkin:\n  port: 70000\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(tt.content), 0o644))
+
+			cfg, err := LoadCheckIn(dir)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
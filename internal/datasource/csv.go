@@ -0,0 +1,88 @@
+package datasource
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ReplaceSessionWinnersInCSV keeps a CSV file of winners for data sources that
+// cannot store them themselves. Like ReplaceSessionWinnersInExcel, it replaces
+// the rows of one session and of earlier sessions of the same event year, and
+// keeps the others. The file uses the columns of the
+// Winners sheet and is rewritten atomically, so a crash never leaves it half written.
+func ReplaceSessionWinnersInCSV(filePath, session string, winners []Winner) error {
+	if session == "" {
+		return errors.New("session is required to replace winners")
+	}
+
+	header := make([]string, len(winnersHeader))
+	for i, h := range winnersHeader {
+		header[i] = fmt.Sprint(h)
+	}
+	records := [][]string{header}
+
+	// Keep the rows of other events
+	existing, err := readCSV(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	replaced := replacesRow(session, winners)
+	for i, record := range existing {
+		if i == 0 || replaced(record) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	for _, w := range withSession(winners, session) {
+		row := w.row()
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = fmt.Sprint(v)
+		}
+		records = append(records, record)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create winners CSV: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // No-op after a successful rename
+
+	w := csv.NewWriter(tmp)
+	if err := w.WriteAll(records); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write winners CSV: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write winners CSV: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write winners CSV: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to save winners CSV: %w", err)
+	}
+	return nil
+}
+
+// readCSV reads every record of a CSV file
+func readCSV(filePath string) ([][]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	return records, nil
+}
@@ -1,7 +1,9 @@
 package datasource
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -40,20 +42,88 @@ func newDBConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	return db, nil
 }
 
-// loadParticipantsFromDB 从数据库加载所有参与者
-func loadParticipantsFromDB(cfg config.DatabaseConfig) ([]model.Participant, error) {
-	db, err := newDBConnection(cfg)
+// dbSource 从数据库读取参与者及其往年中奖记录，奖品读取自 config.yml，中奖结果写回 WinningRecord 表
+type dbSource struct {
+	cfg config.DataSourceConfig
+	db  *gorm.DB
+}
+
+func openDBSource(cfg config.DataSourceConfig) (Source, error) {
+	db, err := newDBConnection(cfg.Database)
 	if err != nil {
 		return nil, err
 	}
+	return &dbSource{cfg: cfg, db: db}, nil
+}
+
+func (s *dbSource) LoadPrizes() ([]model.Prize, error) {
+	return config.LoadPrizes(configDir(s.cfg))
+}
+
+// LoadParticipants 从数据库加载所有参与者
+func (s *dbSource) LoadParticipants() ([]model.Participant, error) {
 
 	var participants []model.Participant
 	// Preload("WinningHistory") 会自动加载关联的往年中奖记录
-	result := db.Preload("WinningHistory").Find(&participants)
+	result := s.db.Preload("WinningHistory").Find(&participants)
 	if result.Error != nil {
 		return nil, fmt.Errorf("查询参与者失败: %w", result.Error)
 	}
 
-	fmt.Printf("成功从数据库 [%s] 加载了 %d 名参与者。\n", cfg.Driver, result.RowsAffected)
+	fmt.Printf("成功从数据库 [%s] 加载了 %d 名参与者。\n", s.cfg.Database.Driver, result.RowsAffected)
 	return participants, nil
 }
+
+// SaveWinners 在一个事务中删除本场之前写入的记录，再为每位中奖者写入一条 WinningRecord。
+// 每次抽奖后都可以调用：重复写入不会产生重复记录，撤销或重置的结果会被删除。
+// 同一活动年份其他场次写入的记录也会删除，换种子重新抽奖后被放弃的结果不会计入中奖历史；
+// 没有场次的记录（如导入的往年数据）保持不变。弃奖者不计入中奖历史，不会写入。
+func (s *dbSource) SaveWinners(session string, winners []Winner) error {
+	if session == "" {
+		return errors.New("保存中奖记录必须指定抽奖场次")
+	}
+
+	eventYear := 0
+	var records []model.WinningRecord
+	for _, winner := range winners {
+		if eventYear == 0 {
+			eventYear = winner.EventYear
+		}
+		if winner.Status != "" && !strings.EqualFold(winner.Status, "Won") {
+			continue
+		}
+		records = append(records, model.WinningRecord{
+			ParticipantID: winner.WinnerID,
+			Year:          winner.EventYear,
+			PrizeLevel:    winner.PrizeLevel,
+			Session:       session,
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		replaced := tx.Where("session = ?", session)
+		if eventYear != 0 {
+			replaced = replaced.Or("year = ? AND session <> ''", eventYear)
+		}
+		if err := replaced.Delete(&model.WinningRecord{}).Error; err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		return fmt.Errorf("保存中奖记录失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *dbSource) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -1,15 +1,19 @@
 package datasource
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
 
 	"github.com/palemoky/lucky-day/internal/config"
+	"github.com/palemoky/lucky-day/internal/lottery"
 	"github.com/palemoky/lucky-day/internal/model"
 )
 
@@ -119,6 +123,194 @@ func TestLoadParticipantsFromExcel(t *testing.T) {
 	}
 }
 
+func TestLoadParticipantsFromExcel_OptionalColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roster.xlsx")
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetName("Sheet1", SheetParticipants))
+	rows := [][]interface{}{
+		{"ID", "Name", "Department", "Email", "Attendance", "Tenure", "Weight"},
+		{1, "张三", "技术部", "zhangsan@company.com", 3, 5.5, 1.5},
+		{2, "李四", "市场部", "lisi@company.com"},
+	}
+	for i, row := range rows {
+		require.NoError(t, f.SetSheetRow(SheetParticipants, fmt.Sprintf("A%d", i+1), &row))
+	}
+	require.NoError(t, f.SaveAs(path))
+	require.NoError(t, f.Close())
+
+	participants, err := LoadParticipantsFromExcel(path)
+	require.NoError(t, err)
+	require.Len(t, participants, 2)
+	assert.Equal(t, "技术部", participants[0].Department)
+	assert.Equal(t, 5.5, participants[0].Tenure)
+	assert.Equal(t, 3, participants[0].Attendance)
+	assert.Equal(t, "zhangsan@company.com", participants[0].Email)
+	assert.Equal(t, 1.5, participants[0].Weight)
+	assert.Zero(t, participants[1].Tenure, "缺少的列应保持零值")
+	assert.Zero(t, participants[1].Attendance)
+}
+
+func TestLoadParticipantsFromExcel_WinningHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roster.xlsx")
+	require.NoError(t, CreateExcelTemplate(path))
+
+	// 往届的中奖名单，最早的一届没有 Status 和 Event Year 列
+	winners := [][]interface{}{
+		{"2023-01-15 20:00:00", "一等奖", 1, "张三", 1},
+		{"2025-01-02 20:00:00", "二等奖", 2, "李四", 2, "Won", 2024},
+		{"2025-01-02 20:00:00", "二等奖", 2, "李四", 2, "Won", 2024},
+		{"2025-01-02 20:05:00", "三等奖", 3, "王五", 3, "Forfeited", 2024},
+		{"2025-01-02 20:05:00", "三等奖", 4, "赵六", 3, "Won", 2024},
+		{"not a date", "三等奖", 5, "孙七", 3},
+	}
+	f, err := excelize.OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, f.SetSheetRow(SheetWinners, "A1", &winnersHeader))
+	for i, row := range winners {
+		require.NoError(t, f.SetSheetRow(SheetWinners, fmt.Sprintf("A%d", i+2), &row))
+	}
+	require.NoError(t, f.Save())
+	require.NoError(t, f.Close())
+
+	participants, err := LoadParticipantsFromExcel(path)
+	require.NoError(t, err)
+	history := make(map[int][]model.WinningRecord)
+	for _, p := range participants {
+		history[p.ID] = p.WinningHistory
+	}
+
+	testCases := []struct {
+		name     string
+		id       int
+		expected []model.WinningRecord
+	}{
+		{name: "没有 Event Year 时使用抽奖时间的年份", id: 1, expected: []model.WinningRecord{{ParticipantID: 1, Year: 2023, PrizeLevel: 1}}},
+		{name: "按 Event Year 记录且重复保存只算一次", id: 2, expected: []model.WinningRecord{{ParticipantID: 2, Year: 2024, PrizeLevel: 2}}},
+		{name: "弃奖不计入中奖记录", id: 3, expected: []model.WinningRecord{}},
+		{name: "补抽的中奖者", id: 4, expected: []model.WinningRecord{{ParticipantID: 4, Year: 2024, PrizeLevel: 3}}},
+		{name: "无法解析的行被跳过", id: 5, expected: []model.WinningRecord{}},
+		{name: "从未中奖", id: 6, expected: []model.WinningRecord{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, history[tc.id])
+		})
+	}
+}
+
+func TestExcelSource_Mapping(t *testing.T) {
+	// 人事系统导出的名单：工作表名称和表头都与模板不同，列的顺序也不同
+	path := filepath.Join(t.TempDir(), "hr.xlsx")
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetName("Sheet1", "员工名单"))
+	rows := [][]interface{}{
+		{"部门", "姓名", "Employee No."},
+		{"技术部", "张三", 1001},
+		{"市场部", "李四", 1002},
+	}
+	for i, row := range rows {
+		require.NoError(t, f.SetSheetRow("员工名单", fmt.Sprintf("A%d", i+1), &row))
+	}
+	_, err := f.NewSheet("奖品")
+	require.NoError(t, err)
+	prizeRows := [][]interface{}{
+		{"数量", "奖品", "等级", "编号", "概率"},
+		{3, "耳机", 2, 1, 0.5},
+	}
+	for i, row := range prizeRows {
+		require.NoError(t, f.SetSheetRow("奖品", fmt.Sprintf("A%d", i+1), &row))
+	}
+	require.NoError(t, f.SaveAs(path))
+	require.NoError(t, f.Close())
+
+	mapping := config.MappingConfig{
+		Sheets:       config.SheetsConfig{Prizes: "奖品", Participants: "员工名单", Winners: "中奖记录"},
+		Prizes:       map[string]string{"id": "编号", "name": "奖品", "count": "数量", "level": "等级", "probability": "概率"},
+		Participants: map[string]string{"id": "Employee No.", "name": "姓名", "department": "部门"},
+	}
+	src, err := Open(config.DataSourceConfig{Type: "excel", Excel: config.ExcelConfig{Path: path}, Mapping: mapping})
+	require.NoError(t, err)
+	defer func() { _ = src.Close() }()
+
+	participants, err := src.LoadParticipants()
+	require.NoError(t, err)
+	require.Len(t, participants, 2)
+	assert.Equal(t, 1001, participants[0].ID)
+	assert.Equal(t, "张三", participants[0].Name)
+	assert.Equal(t, "技术部", participants[0].Department)
+
+	prizes, err := src.LoadPrizes()
+	require.NoError(t, err)
+	require.Len(t, prizes, 1)
+	assert.Equal(t, model.Prize{ID: 1, Name: "耳机", Level: 2, Count: 3, Probability: 0.5}, prizes[0])
+
+	// 中奖记录写入映射的工作表，没有时自动创建，下一届作为中奖历史读取
+	require.NoError(t, src.SaveWinners("s1", []Winner{{PrizeName: "耳机", WinnerID: 1001, WinnerName: "张三", PrizeLevel: 2, Status: "Won", EventYear: 2025}}))
+	participants, err = src.LoadParticipants()
+	require.NoError(t, err)
+	assert.Equal(t, []model.WinningRecord{{ParticipantID: 1001, Year: 2025, PrizeLevel: 2}}, participants[0].WinningHistory)
+}
+
+func TestResolveColumns(t *testing.T) {
+	header := []string{"Employee No.", "姓名", "Department"}
+
+	t.Run("未配置映射时按默认表头和位置识别", func(t *testing.T) {
+		columns, err := resolveColumns("Participants sheet", "participants", header, participantFields, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"id": 0, "name": 1, "department": 2}, columns)
+	})
+
+	t.Run("缺少必填表头时列出所有缺少的表头", func(t *testing.T) {
+		_, err := resolveColumns("员工名单 sheet", "participants", header, participantFields, map[string]string{"id": "工号", "name": "Name", "department": "部门"})
+		require.Error(t, err)
+		assert.Equal(t, `员工名单 sheet is missing required headers: "工号" (id), "name" (name)`, err.Error())
+	})
+
+	t.Run("未知字段", func(t *testing.T) {
+		_, err := resolveColumns("Participants sheet", "participants", header, participantFields, map[string]string{"phone": "电话"})
+		assert.ErrorContains(t, err, `unknown field "phone" in mapping.participants`)
+	})
+
+	t.Run("CSV名单使用映射", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "hr.csv")
+		require.NoError(t, os.WriteFile(csvPath, []byte("姓名,Employee No.\n张三,1001\n"), 0o644))
+		participants, _, err := loadParticipantsFromCSV(csvPath, map[string]string{"id": "Employee No.", "name": "姓名"})
+		require.NoError(t, err)
+		require.Len(t, participants, 1)
+		assert.Equal(t, 1001, participants[0].ID)
+		assert.Equal(t, "张三", participants[0].Name)
+
+		_, _, err = loadParticipantsFromCSV(csvPath, map[string]string{"id": "工号", "name": "姓名"})
+		assert.ErrorContains(t, err, `hr.csv is missing required headers: "工号" (id)`)
+	})
+}
+
+func TestLoadPrizesFromExcel_Rules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prizes.xlsx")
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetName("Sheet1", SheetPrizes))
+	rows := [][]interface{}{
+		{"ID", "Name (CN)", "Name (EN)", "Count", "Level", "Probability", "Quotas", "Eligibility", "BatchSize"},
+		{1, "购物卡", "Shopping Card", 10, 3, 0.9, "department <= 2； department >= 1", "id != 1,2; tenure >= 1", 5},
+		{2, "耳机", "Headphones", 5, 2, 0.6},
+	}
+	for i, row := range rows {
+		require.NoError(t, f.SetSheetRow(SheetPrizes, fmt.Sprintf("A%d", i+1), &row))
+	}
+	require.NoError(t, f.SaveAs(path))
+	require.NoError(t, f.Close())
+
+	prizes, err := LoadPrizesFromExcel(path)
+	require.NoError(t, err)
+	require.Len(t, prizes, 2)
+	assert.Equal(t, []string{"department <= 2", "department >= 1"}, prizes[0].Quotas)
+	assert.Equal(t, []string{"id != 1,2", "tenure >= 1"}, prizes[0].Eligibility)
+	assert.Empty(t, prizes[1].Quotas, "没有配额的奖项应为空")
+	assert.Empty(t, prizes[1].Eligibility)
+	assert.Equal(t, 5, prizes[0].BatchSize)
+	assert.Zero(t, prizes[1].BatchSize, "没有每批人数的奖项应一次抽完")
+}
+
 func TestSaveWinnersToExcel(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -141,6 +333,8 @@ func TestSaveWinnersToExcel(t *testing.T) {
 					WinnerID:   2,
 					WinnerName: "李四",
 					PrizeLevel: 1,
+					Status:     "Forfeited",
+					EventYear:  2025,
 				},
 			},
 			wantErr: false,
@@ -175,10 +369,133 @@ func TestSaveWinnersToExcel(t *testing.T) {
 			// 验证文件仍然存在
 			_, err = os.Stat(outputPath)
 			assert.NoError(t, err, "输出文件应该存在")
+
+			// 验证状态列被写入
+			f, err := excelize.OpenFile(outputPath)
+			require.NoError(t, err)
+			defer func() { _ = f.Close() }()
+			rows, err := f.GetRows(SheetWinners)
+			require.NoError(t, err)
+			require.Len(t, rows, len(tt.winners)+1)
+			assert.Equal(t, "Status", rows[0][5])
+			assert.Equal(t, "Event Year", rows[0][6])
+			for i, w := range tt.winners {
+				assert.Equal(t, w.Status, cellAt(rows[i+1], headerColumns(rows[0]), "status"))
+			}
 		})
 	}
 }
 
+func TestSaveWinnersToExcel_UpgradesHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "winners.xlsx")
+	require.NoError(t, CreateExcelTemplate(path))
+
+	// 旧版本的 Winners 表没有 Status 和 Event Year 列
+	f, err := excelize.OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, f.SetSheetRow(SheetWinners, "A1", &[]interface{}{"Draw Time", "Prize Name", "Winner ID", "Winner Name", "Prize Level"}))
+	require.NoError(t, f.SetSheetRow(SheetWinners, "A2", &[]interface{}{"2024-12-31 20:00:00", "一等奖", 1, "张三", 1}))
+	require.NoError(t, f.Save())
+	require.NoError(t, f.Close())
+
+	winners := []Winner{{DrawTime: time.Date(2026, 1, 2, 20, 0, 0, 0, time.Local), PrizeName: "二等奖", WinnerID: 2, WinnerName: "李四", PrizeLevel: 2, Status: "Won", EventYear: 2025}}
+	require.NoError(t, SaveWinnersToExcel(path, winners))
+
+	f, err = excelize.OpenFile(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	rows, err := f.GetRows(SheetWinners)
+	require.NoError(t, err)
+	require.Len(t, rows, 3, "已有的中奖记录应保留")
+	header := headerColumns(rows[0])
+	assert.Equal(t, "张三", cellAt(rows[1], header, "winner name"))
+	assert.Equal(t, "2025", cellAt(rows[2], header, "event year"))
+}
+
+func TestReplaceSessionWinnersInExcel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "winners.xlsx")
+	require.NoError(t, CreateExcelTemplate(path))
+
+	drawTime := time.Date(2026, 1, 2, 20, 0, 0, 0, time.Local)
+	winner := func(id int, name, session string) Winner {
+		return Winner{DrawTime: drawTime, PrizeName: "一等奖", WinnerID: id, WinnerName: name, PrizeLevel: 1, Status: "Won", EventYear: 2025, Session: session}
+	}
+
+	// 往年场次的记录不受影响
+	other := winner(9, "赵六", "other")
+	other.EventYear = 2024
+	require.NoError(t, SaveWinnersToExcel(path, []Winner{other}))
+
+	// 每次抽奖后保存全部结果，重复保存不会产生重复记录
+	require.NoError(t, ReplaceSessionWinnersInExcel(path, "s1", []Winner{winner(1, "张三", "s1")}))
+	require.NoError(t, ReplaceSessionWinnersInExcel(path, "s1", []Winner{winner(1, "张三", "s1"), winner(2, "李四", "s1")}))
+	require.NoError(t, ReplaceSessionWinnersInExcel(path, "s1", []Winner{winner(1, "张三", "s1"), winner(2, "李四", "s1")}))
+
+	// 撤销后李四的记录被删除
+	require.NoError(t, ReplaceSessionWinnersInExcel(path, "s1", []Winner{winner(1, "张三", "s1")}))
+
+	f, err := excelize.OpenFile(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	rows, err := f.GetRows(SheetWinners)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	header := headerColumns(rows[0])
+	assert.Equal(t, "赵六", cellAt(rows[1], header, "winner name"))
+	assert.Equal(t, "张三", cellAt(rows[2], header, "winner name"))
+	assert.Equal(t, "s1", cellAt(rows[2], header, "session"))
+
+	// 同一年换种子重新抽奖，替换掉被放弃的那一场
+	require.NoError(t, ReplaceSessionWinnersInExcel(path, "s2", []Winner{winner(3, "王五", "s2")}))
+	f2, err := excelize.OpenFile(path)
+	require.NoError(t, err)
+	defer func() { _ = f2.Close() }()
+	rows, err = f2.GetRows(SheetWinners)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "赵六", cellAt(rows[1], header, "winner name"))
+	assert.Equal(t, "王五", cellAt(rows[2], header, "winner name"))
+
+	assert.Error(t, ReplaceSessionWinnersInExcel(path, "", nil), "必须指定场次")
+}
+
+func TestReplaceSessionWinnersInCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "winners.csv")
+	drawTime := time.Date(2026, 1, 2, 20, 0, 0, 0, time.Local)
+	winner := func(id int, name, session string) Winner {
+		return Winner{DrawTime: drawTime, PrizeName: "一等奖", WinnerID: id, WinnerName: name, PrizeLevel: 1, Status: "Won", EventYear: 2025, Session: session}
+	}
+
+	// 文件不存在时自动创建，往年场次的记录不受影响
+	other := winner(9, "赵六", "other")
+	other.EventYear = 2024
+	require.NoError(t, ReplaceSessionWinnersInCSV(path, "other", []Winner{other}))
+	require.NoError(t, ReplaceSessionWinnersInCSV(path, "s1", []Winner{winner(1, "张三", "s1"), winner(2, "李四", "s1")}))
+	require.NoError(t, ReplaceSessionWinnersInCSV(path, "s1", []Winner{winner(2, "李四", "s1")}))
+
+	records, err := readCSV(path)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"Draw Time", "Prize Name", "Winner ID", "Winner Name", "Prize Level", "Status", "Event Year", "Session"}, records[0])
+	assert.Equal(t, []string{"2026-01-02 20:00:00", "一等奖", "9", "赵六", "1", "Won", "2024", "other"}, records[1])
+	assert.Equal(t, "李四", records[2][3])
+
+	// 同一年换种子重新抽奖，替换掉被放弃的那一场
+	require.NoError(t, ReplaceSessionWinnersInCSV(path, "s2", []Winner{winner(3, "王五", "s2")}))
+	records, err = readCSV(path)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "赵六", records[1][3])
+	assert.Equal(t, []string{"王五", "s2"}, []string{records[2][3], records[2][7]})
+
+	// 不留下临时文件
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Error(t, ReplaceSessionWinnersInCSV(path, "", nil), "必须指定场次")
+}
+
 func TestCreateExcelTemplate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -250,6 +567,31 @@ func TestLoadParticipantsFromCSV(t *testing.T) {
 				assert.Equal(t, "张三", participants[0].Name)
 				assert.Equal(t, 2, participants[1].ID)
 				assert.Equal(t, "李四", participants[1].Name)
+				assert.Equal(t, "技术部", participants[0].Department)
+				assert.Equal(t, "zhangsan@example.com", participants[0].Email)
+			},
+		},
+		{
+			name: "按表头识别列",
+			setupFunc: func(t *testing.T) string {
+				csvPath := filepath.Join(t.TempDir(), "participants.csv")
+				content := `Name,Email,ID,Tenure,Weight,Department
+张三,zhangsan@example.com,7,3.5,2,技术部
+李四,,8,abc,-1,
+`
+				require.NoError(t, os.WriteFile(csvPath, []byte(content), 0o644))
+				return csvPath
+			},
+			validate: func(t *testing.T, participants []model.Participant) {
+				require.Len(t, participants, 2)
+				assert.Equal(t, model.Participant{
+					ID: 7, Name: "张三", Department: "技术部", Email: "zhangsan@example.com",
+					Tenure: 3.5, Weight: 2, WinningHistory: []model.WinningRecord{},
+				}, participants[0])
+				// 无效的可选列会被忽略，该行仍然保留
+				assert.Equal(t, 8, participants[1].ID)
+				assert.Zero(t, participants[1].Tenure)
+				assert.Zero(t, participants[1].Weight)
 			},
 		},
 		{
@@ -281,7 +623,7 @@ func TestLoadParticipantsFromCSV(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			path := tt.setupFunc(t)
 
-			participants, err := loadParticipantsFromCSV(path)
+			participants, _, err := loadParticipantsFromCSV(path, nil)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -297,6 +639,102 @@ func TestLoadParticipantsFromCSV(t *testing.T) {
 	}
 }
 
+func TestCSVSource_WinningHistory(t *testing.T) {
+	dir := t.TempDir()
+	rosterPath := filepath.Join(dir, "participants.csv")
+	require.NoError(t, os.WriteFile(rosterPath, []byte("ID,Name\n1,张三\n2,李四\n"), 0o644))
+
+	t.Run("默认读取保存的中奖结果", func(t *testing.T) {
+		src, err := Open(config.DataSourceConfig{Type: "csv", CSV: config.CSVConfig{Path: rosterPath}})
+		require.NoError(t, err)
+
+		// 第一次活动时还没有中奖结果
+		participants, err := src.LoadParticipants()
+		require.NoError(t, err)
+		assert.Empty(t, participants[0].WinningHistory)
+
+		require.NoError(t, src.SaveWinners("s1", []Winner{
+			{DrawTime: time.Date(2025, 1, 20, 20, 0, 0, 0, time.Local), PrizeName: "一等奖", WinnerID: 1, WinnerName: "张三", PrizeLevel: 1, Status: "Won", EventYear: 2024},
+			{DrawTime: time.Date(2025, 1, 20, 20, 0, 0, 0, time.Local), PrizeName: "一等奖", WinnerID: 2, WinnerName: "李四", PrizeLevel: 1, Status: "Forfeited", EventYear: 2024},
+		}))
+
+		participants, err = src.LoadParticipants()
+		require.NoError(t, err)
+		assert.Equal(t, []model.WinningRecord{{ParticipantID: 1, Year: 2024, PrizeLevel: 1}}, participants[0].WinningHistory)
+		assert.Empty(t, participants[1].WinningHistory, "弃奖不计入中奖历史")
+	})
+
+	t.Run("指定中奖历史文件", func(t *testing.T) {
+		historyPath := filepath.Join(dir, "history.csv")
+		require.NoError(t, os.WriteFile(historyPath, []byte("Winner ID,Prize Level,Event Year\n2,3,2023\n"), 0o644))
+		src, err := Open(config.DataSourceConfig{Type: "csv", CSV: config.CSVConfig{Path: rosterPath, History: historyPath}})
+		require.NoError(t, err)
+
+		participants, err := src.LoadParticipants()
+		require.NoError(t, err)
+		assert.Empty(t, participants[0].WinningHistory)
+		assert.Equal(t, []model.WinningRecord{{ParticipantID: 2, Year: 2023, PrizeLevel: 3}}, participants[1].WinningHistory)
+	})
+
+	t.Run("指定的中奖历史文件不存在", func(t *testing.T) {
+		src, err := Open(config.DataSourceConfig{Type: "csv", CSV: config.CSVConfig{Path: rosterPath, History: filepath.Join(dir, "missing.csv")}})
+		require.NoError(t, err)
+		_, err = src.LoadParticipants()
+		assert.Error(t, err)
+	})
+}
+
+func TestRowErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("CSV名单", func(t *testing.T) {
+		path := filepath.Join(dir, "roster.csv")
+		require.NoError(t, os.WriteFile(path, []byte("ID,Name,Weight\n1,张三,\nx,李四,\n3,王五,-1\n"), 0o644))
+		src, err := Open(config.DataSourceConfig{Type: "csv", CSV: config.CSVConfig{Path: path}})
+		require.NoError(t, err)
+
+		participants, err := src.LoadParticipants()
+		require.NoError(t, err)
+		assert.Len(t, participants, 2)
+
+		rowErrs := RowErrors(src)
+		require.Len(t, rowErrs, 2)
+		assert.Equal(t, "roster.csv", rowErrs[0].Source)
+		assert.Equal(t, 3, rowErrs[0].Row)
+		assert.True(t, rowErrs[0].Skipped)
+		assert.Equal(t, 4, rowErrs[1].Row)
+		assert.False(t, rowErrs[1].Skipped, "无效的可选列只忽略该值")
+	})
+
+	t.Run("Excel奖品", func(t *testing.T) {
+		path := filepath.Join(dir, "prizes.xlsx")
+		f := excelize.NewFile()
+		require.NoError(t, f.SetSheetName("Sheet1", SheetPrizes))
+		for i, row := range [][]interface{}{
+			{"ID", "Name (CN)", "Name (EN)", "Count", "Level", "Probability"},
+			{1, "一等奖", "First", 1, 1, 0.1},
+			{2, "二等奖", "Second", "many", 2, 0.2},
+		} {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			require.NoError(t, f.SetSheetRow(SheetPrizes, cell, &row))
+		}
+		require.NoError(t, f.SaveAs(path))
+
+		src, err := Open(config.DataSourceConfig{Type: "excel", Excel: config.ExcelConfig{Path: path}})
+		require.NoError(t, err)
+		prizes, err := src.LoadPrizes()
+		require.NoError(t, err)
+		assert.Len(t, prizes, 1)
+		require.Len(t, RowErrors(src), 1)
+		assert.Equal(t, RowError{Source: SheetPrizes, Row: 3, Skipped: true, Err: RowErrors(src)[0].Err}, RowErrors(src)[0])
+		assert.ErrorContains(t, RowErrors(src)[0], "Prizes row 3 skipped: invalid Count")
+	})
+
+	t.Run("不支持的数据源", func(t *testing.T) {
+		assert.Nil(t, RowErrors(&checkInSource{prizes: &dbSource{}}))
+	})
+}
+
 func TestLoadParticipants(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -373,3 +811,283 @@ func TestLoadParticipants(t *testing.T) {
 		})
 	}
 }
+
+func TestParticipantAttributes(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("CSV名单的其他列", func(t *testing.T) {
+		path := filepath.Join(dir, "roster.csv")
+		require.NoError(t, os.WriteFile(path, []byte("ID,Name,Department,职级,Site\n1,张三,研发部,P7,上海\n2,李四,市场部,,\n"), 0o644))
+		participants, _, err := loadParticipantsFromCSV(path, nil)
+		require.NoError(t, err)
+		require.Len(t, participants, 2)
+		assert.Equal(t, "研发部", participants[0].Department)
+		assert.Equal(t, map[string]string{"职级": "P7", "Site": "上海"}, participants[0].Attributes)
+		assert.Nil(t, participants[1].Attributes, "空值不保存")
+
+		site, ok := participants[0].Attribute("site")
+		assert.True(t, ok)
+		assert.Equal(t, "上海", site)
+	})
+
+	t.Run("Excel名单的其他列", func(t *testing.T) {
+		path := filepath.Join(dir, "roster.xlsx")
+		f := excelize.NewFile()
+		require.NoError(t, f.SetSheetName("Sheet1", SheetParticipants))
+		for i, row := range [][]interface{}{
+			{"ID", "Name", "Phone", "Email"},
+			{1, "张三", "13800000000", "zhangsan@example.com"},
+		} {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			require.NoError(t, f.SetSheetRow(SheetParticipants, cell, &row))
+		}
+		require.NoError(t, f.SaveAs(path))
+
+		participants, err := LoadParticipantsFromExcel(path)
+		require.NoError(t, err)
+		require.Len(t, participants, 1)
+		assert.Equal(t, "zhangsan@example.com", participants[0].Email)
+		assert.Equal(t, map[string]string{"Phone": "13800000000"}, participants[0].Attributes)
+	})
+
+	t.Run("数据库的JSON列", func(t *testing.T) {
+		dbCfg := config.DatabaseConfig{Driver: "sqlite", DSN: filepath.Join(dir, "lottery.db")}
+		db, err := newDBConnection(dbCfg)
+		require.NoError(t, err)
+		require.NoError(t, db.Create(&model.Participant{ID: 1, Name: "张三", Attributes: map[string]string{"职级": "P7"}}).Error)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		participants, err := LoadParticipants(config.DataSourceConfig{Type: "db", Database: dbCfg})
+		require.NoError(t, err)
+		require.Len(t, participants, 1)
+		assert.Equal(t, map[string]string{"职级": "P7"}, participants[0].Attributes)
+	})
+}
+
+func TestDBSource_SaveWinners(t *testing.T) {
+	dbCfg := config.DatabaseConfig{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "lottery.db")}
+
+	// 准备参与者及一条往年的中奖记录
+	db, err := newDBConnection(dbCfg)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&[]model.Participant{{ID: 1, Name: "张三"}, {ID: 2, Name: "李四"}, {ID: 3, Name: "王五"}}).Error)
+	require.NoError(t, db.Create(&model.WinningRecord{ParticipantID: 3, Year: 2024, PrizeLevel: 2}).Error)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	src, err := Open(config.DataSourceConfig{Type: "db", Database: dbCfg})
+	require.NoError(t, err)
+	defer func() { _ = src.Close() }()
+
+	history := func() map[int][]model.WinningRecord {
+		participants, err := src.LoadParticipants()
+		require.NoError(t, err)
+		history := make(map[int][]model.WinningRecord)
+		for _, p := range participants {
+			history[p.ID] = p.WinningHistory
+		}
+		return history
+	}
+
+	winners := []Winner{
+		{WinnerID: 1, WinnerName: "张三", PrizeLevel: 1, Status: "Won", EventYear: 2025},
+		{WinnerID: 2, WinnerName: "李四", PrizeLevel: 1, Status: "Forfeited", EventYear: 2025},
+	}
+	// 每次抽奖后写入全部结果，重复写入不会产生重复记录
+	require.NoError(t, src.SaveWinners("s1", winners))
+	require.NoError(t, src.SaveWinners("s1", winners))
+
+	h := history()
+	require.Len(t, h[1], 1)
+	assert.Equal(t, 2025, h[1][0].Year)
+	assert.Equal(t, 1, h[1][0].PrizeLevel)
+	assert.Empty(t, h[2], "弃奖不计入中奖历史")
+	assert.Len(t, h[3], 1, "其他场次的记录不受影响")
+
+	// 撤销后本场的记录被删除
+	require.NoError(t, src.SaveWinners("s1", nil))
+	h = history()
+	assert.Empty(t, h[1])
+	assert.Len(t, h[3], 1)
+
+	assert.Error(t, src.SaveWinners("", winners), "必须指定场次")
+}
+
+func TestDBSource_SaveWinnersRerun(t *testing.T) {
+	dbCfg := config.DatabaseConfig{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "lottery.db")}
+	db, err := newDBConnection(dbCfg)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&[]model.Participant{{ID: 1, Name: "张三"}, {ID: 2, Name: "李四"}, {ID: 3, Name: "王五"}}).Error)
+	require.NoError(t, db.Create(&model.WinningRecord{ParticipantID: 3, Year: 2025, PrizeLevel: 2}).Error)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer func() { _ = sqlDB.Close() }()
+
+	src, err := Open(config.DataSourceConfig{Type: "db", Database: dbCfg})
+	require.NoError(t, err)
+	defer func() { _ = src.Close() }()
+
+	// 去年的场次不受影响
+	require.NoError(t, src.SaveWinners("last-year", []Winner{{WinnerID: 2, PrizeLevel: 1, Status: "Won", EventYear: 2024}}))
+	// 同一年换种子重新抽奖，只保留第二场的结果
+	require.NoError(t, src.SaveWinners("A", []Winner{{WinnerID: 1, PrizeLevel: 1, Status: "Won", EventYear: 2025}}))
+	require.NoError(t, src.SaveWinners("B", []Winner{{WinnerID: 2, PrizeLevel: 3, Status: "Won", EventYear: 2025}}))
+
+	var records []model.WinningRecord
+	require.NoError(t, db.Order("participant_id, year").Find(&records).Error)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"last-year", "B", ""}, []string{records[0].Session, records[1].Session, records[2].Session})
+	assert.Equal(t, 3, records[2].ParticipantID, "没有场次的记录保持不变")
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	rosterPath := filepath.Join(dir, "roster.csv")
+	require.NoError(t, os.WriteFile(rosterPath, []byte("ID,Name\n1,张三\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte("prizes:\n  - id: 1\n    name: 一等奖\n    count: 1\n"), 0o644))
+
+	t.Run("CSV数据源", func(t *testing.T) {
+		src, err := Open(config.DataSourceConfig{Type: "csv", CSV: config.CSVConfig{Path: rosterPath}, ConfigDir: dir})
+		require.NoError(t, err)
+		defer func() { _ = src.Close() }()
+
+		prizes, err := src.LoadPrizes()
+		require.NoError(t, err)
+		require.Len(t, prizes, 1)
+		assert.Equal(t, "一等奖", prizes[0].Name)
+
+		participants, err := src.LoadParticipants()
+		require.NoError(t, err)
+		assert.Len(t, participants, 1)
+
+		// 中奖结果默认保存到名单所在目录
+		require.NoError(t, src.SaveWinners("s1", []Winner{{WinnerID: 1, WinnerName: "张三", Status: "Won"}}))
+		records, err := readCSV(filepath.Join(dir, DefaultWinnersFile))
+		require.NoError(t, err)
+		assert.Len(t, records, 2)
+	})
+
+	t.Run("签到数据源", func(t *testing.T) {
+		base, err := Open(config.DataSourceConfig{Type: "csv", CSV: config.CSVConfig{Path: rosterPath}, ConfigDir: dir})
+		require.NoError(t, err)
+		winnersPath := filepath.Join(dir, "checkin_winners.csv")
+		src := NewCheckInSource(base, []model.Participant{{ID: 1, Name: "签到者"}}, winnersPath)
+		defer func() { _ = src.Close() }()
+
+		prizes, err := src.LoadPrizes()
+		require.NoError(t, err)
+		assert.Len(t, prizes, 1)
+		participants, err := src.LoadParticipants()
+		require.NoError(t, err)
+		assert.Equal(t, "签到者", participants[0].Name)
+
+		require.NoError(t, src.SaveWinners("s1", []Winner{{WinnerID: 1, WinnerName: "签到者", Status: "Won"}}))
+		records, err := readCSV(winnersPath)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, "s1", records[1][len(records[1])-1])
+	})
+
+	t.Run("按名单签到的数据源", func(t *testing.T) {
+		winnersPath := filepath.Join(dir, "present_winners.csv")
+		base, err := Open(config.DataSourceConfig{Type: "csv", CSV: config.CSVConfig{Path: rosterPath, Winners: winnersPath}, ConfigDir: dir})
+		require.NoError(t, err)
+		src := NewPresentSource(base, []model.Participant{{ID: 1, Name: "张三"}})
+		defer func() { _ = src.Close() }()
+
+		prizes, err := src.LoadPrizes()
+		require.NoError(t, err)
+		assert.Len(t, prizes, 1)
+		participants, err := src.LoadParticipants()
+		require.NoError(t, err)
+		assert.Equal(t, []model.Participant{{ID: 1, Name: "张三"}}, participants)
+
+		// 中奖结果保存到名单所在的数据源
+		require.NoError(t, src.SaveWinners("s1", []Winner{{WinnerID: 1, WinnerName: "张三", Status: "Won"}}))
+		records, err := readCSV(winnersPath)
+		require.NoError(t, err)
+		assert.Len(t, records, 2)
+	})
+
+	t.Run("注册新的数据源", func(t *testing.T) {
+		Register("test", func(cfg config.DataSourceConfig) (Source, error) {
+			return NewCheckInSource(&csvSource{cfg: cfg}, nil, ""), nil
+		})
+		t.Cleanup(func() {
+			registryMu.Lock()
+			defer registryMu.Unlock()
+			delete(registry, "test")
+		})
+		assert.Contains(t, Types(), "test")
+		_, err := Open(config.DataSourceConfig{Type: "test"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("未知数据源类型", func(t *testing.T) {
+		_, err := Open(config.DataSourceConfig{Type: "unknown"})
+		assert.ErrorContains(t, err, "unknown")
+	})
+}
+
+// 保存本届中奖结果后重新读取名单，复核仍然通过，本届的中奖结果不计入往年中奖历史
+func TestSaveReloadVerify(t *testing.T) {
+	const eventYear = 2025
+
+	excelDir := t.TempDir()
+	excelPath := filepath.Join(excelDir, "lottery.xlsx")
+	require.NoError(t, CreateExcelTemplate(excelPath))
+
+	csvDir := t.TempDir()
+	csvPath := filepath.Join(csvDir, "participants.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("ID,Name\n1,张三\n2,李四\n3,王五\n4,赵六\n5,钱七\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(csvDir, "config.yml"), []byte("prizes:\n  - id: 1\n    name: 一等奖\n    count: 2\n    level: 1\n"), 0o644))
+
+	tests := []struct {
+		name string
+		cfg  config.DataSourceConfig
+	}{
+		{name: "Excel数据源", cfg: config.DataSourceConfig{Type: "excel", Excel: config.ExcelConfig{Path: excelPath}, ConfigDir: excelDir}},
+		{name: "CSV数据源", cfg: config.DataSourceConfig{Type: "csv", CSV: config.CSVConfig{Path: csvPath}, ConfigDir: csvDir}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := Open(tt.cfg)
+			require.NoError(t, err)
+			defer func() { _ = src.Close() }()
+			prizes, err := src.LoadPrizes()
+			require.NoError(t, err)
+			participants, err := src.LoadParticipants()
+			require.NoError(t, err)
+
+			engine := lottery.NewEngine(lottery.PriorHistory(participants, eventYear), prizes, 42)
+			engine.SetEventYear(eventYear)
+			commitment, err := engine.Commit()
+			require.NoError(t, err)
+			var winners []Winner
+			for _, prize := range prizes {
+				drawn, err := engine.Draw(prize.ID)
+				require.NoError(t, err)
+				for _, p := range drawn {
+					winners = append(winners, Winner{PrizeName: prize.Name, WinnerID: p.ID, WinnerName: p.Name, PrizeLevel: int(prize.Level), Status: lottery.StatusWon, EventYear: eventYear})
+				}
+			}
+			require.NotEmpty(t, winners)
+			require.NoError(t, src.SaveWinners(commitment, winners))
+
+			reloaded, err := src.LoadParticipants()
+			require.NoError(t, err)
+			winner := slices.IndexFunc(reloaded, func(p model.Participant) bool { return p.ID == winners[0].WinnerID })
+			require.GreaterOrEqual(t, winner, 0)
+			assert.Contains(t, reloaded[winner].WinningHistory, model.WinningRecord{ParticipantID: winners[0].WinnerID, Year: eventYear, PrizeLevel: winners[0].PrizeLevel},
+				"重新读取的名单包含本届保存的中奖结果")
+
+			report := lottery.Verify(reloaded, prizes, nil, engine.Reveal(), commitment)
+			assert.True(t, report.CommitmentOK)
+			assert.True(t, report.Passed(), report.Errors)
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package datasource
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/xuri/excelize/v2"
 
+	"github.com/palemoky/lucky-day/internal/config"
 	"github.com/palemoky/lucky-day/internal/model"
 )
 
@@ -15,11 +19,18 @@ const (
 	SheetWinners      = "Winners"
 )
 
-// LoadPrizesFromExcel loads prizes from Excel file
+// LoadPrizesFromExcel loads prizes from the Prizes sheet of an Excel file
 func LoadPrizesFromExcel(filePath string) ([]model.Prize, error) {
+	prizes, _, err := loadPrizesFromExcel(filePath, mappingFor(config.DataSourceConfig{}))
+	return prizes, err
+}
+
+// loadPrizesFromExcel loads prizes using the sheet name and headers of mapping,
+// along with the rows it could not parse
+func loadPrizesFromExcel(filePath string, mapping config.MappingConfig) ([]model.Prize, []RowError, error) {
 	f, err := excelize.OpenFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open Excel file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open Excel file: %w", err)
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -27,18 +38,28 @@ func LoadPrizesFromExcel(filePath string) ([]model.Prize, error) {
 		}
 	}()
 
-	rows, err := f.GetRows(SheetPrizes)
+	sheet := mapping.Sheets.Prizes
+	rows, err := f.GetRows(sheet)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read Prizes sheet: %w", err)
+		return nil, nil, fmt.Errorf("failed to read %s sheet: %w", sheet, err)
 	}
 
 	if len(rows) <= 1 {
-		return nil, fmt.Errorf("prizes sheet is empty or only contains header")
+		return nil, nil, fmt.Errorf("%s sheet is empty or only contains header", sheet)
+	}
+
+	// Columns are located by header name, see resolveColumns
+	header, err := resolveColumns(sheet+" sheet", "prizes", rows[0], prizeFields, mapping.Prizes)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	var prizes []model.Prize
+	var (
+		prizes  []model.Prize
+		rowErrs rowErrors
+	)
 	for i, row := range rows[1:] { // Skip header
-		if len(row) < 6 {
+		if cellAt(row, header, "probability") == "" {
 			continue // Skip incomplete rows
 		}
 
@@ -46,49 +67,68 @@ func LoadPrizesFromExcel(filePath string) ([]model.Prize, error) {
 		var probability float64
 
 		// Parse ID
-		if _, err := fmt.Sscanf(row[0], "%d", &id); err != nil {
-			fmt.Printf("warning: skipping row %d, invalid ID: %v\n", i+2, err)
+		if _, err := fmt.Sscanf(cellAt(row, header, "id"), "%d", &id); err != nil {
+			fmt.Printf("warning: %v\n", rowErrs.add(sheet, i+2, true, fmt.Errorf("invalid ID: %w", err)))
 			continue
 		}
 
 		// Parse Count
-		if _, err := fmt.Sscanf(row[3], "%d", &count); err != nil {
-			fmt.Printf("warning: skipping row %d, invalid Count: %v\n", i+2, err)
+		if _, err := fmt.Sscanf(cellAt(row, header, "count"), "%d", &count); err != nil {
+			fmt.Printf("warning: %v\n", rowErrs.add(sheet, i+2, true, fmt.Errorf("invalid Count: %w", err)))
 			continue
 		}
 
 		// Parse Level
-		if _, err := fmt.Sscanf(row[4], "%d", &level); err != nil {
-			fmt.Printf("warning: skipping row %d, invalid Level: %v\n", i+2, err)
+		if _, err := fmt.Sscanf(cellAt(row, header, "level"), "%d", &level); err != nil {
+			fmt.Printf("warning: %v\n", rowErrs.add(sheet, i+2, true, fmt.Errorf("invalid Level: %w", err)))
 			continue
 		}
 
 		// Parse Probability
-		if _, err := fmt.Sscanf(row[5], "%f", &probability); err != nil {
-			fmt.Printf("warning: skipping row %d, invalid Probability: %v\n", i+2, err)
+		if _, err := fmt.Sscanf(cellAt(row, header, "probability"), "%f", &probability); err != nil {
+			fmt.Printf("warning: %v\n", rowErrs.add(sheet, i+2, true, fmt.Errorf("invalid Probability: %w", err)))
 			continue
 		}
 
+		// Parse optional BatchSize, empty means draw all remaining slots at once
+		var batchSize int
+		if v := cellAt(row, header, "batch_size"); v != "" {
+			if _, err := fmt.Sscanf(v, "%d", &batchSize); err != nil || batchSize < 0 {
+				fmt.Printf("warning: %v\n", rowErrs.add(sheet, i+2, false, fmt.Errorf("invalid BatchSize %q, drawing all at once", v)))
+				batchSize = 0
+			}
+		}
+
 		prize := model.Prize{
 			ID:          id,
-			Name:        row[1], // Use Chinese name by default
+			Name:        cellAt(row, header, "name"), // Use Chinese name by default
 			Level:       model.PrizeLevel(level),
 			Count:       count,
 			Probability: probability,
 			DrawnCount:  0,
+			Quotas:      splitRules(cellAt(row, header, "quotas")),
+			Eligibility: splitRules(cellAt(row, header, "eligibility")),
+			BatchSize:   batchSize,
 		}
 		prizes = append(prizes, prize)
 	}
 
 	fmt.Printf("Successfully loaded %d prizes from Excel file [%s]\n", len(prizes), filePath)
-	return prizes, nil
+	return prizes, rowErrs, nil
 }
 
-// LoadParticipantsFromExcel loads participants from Excel file
+// LoadParticipantsFromExcel loads participants from the Participants sheet of an Excel file
 func LoadParticipantsFromExcel(filePath string) ([]model.Participant, error) {
+	participants, _, err := loadParticipantsFromExcel(filePath, mappingFor(config.DataSourceConfig{}))
+	return participants, err
+}
+
+// loadParticipantsFromExcel loads participants using the sheet names and headers
+// of mapping, along with the roster and history rows it could not parse
+func loadParticipantsFromExcel(filePath string, mapping config.MappingConfig) ([]model.Participant, []RowError, error) {
 	f, err := excelize.OpenFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open Excel file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open Excel file: %w", err)
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -96,13 +136,27 @@ func LoadParticipantsFromExcel(filePath string) ([]model.Participant, error) {
 		}
 	}()
 
-	rows, err := f.GetRows(SheetParticipants)
+	sheet := mapping.Sheets.Participants
+	rows, err := f.GetRows(sheet)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read Participants sheet: %w", err)
+		return nil, nil, fmt.Errorf("failed to read %s sheet: %w", sheet, err)
 	}
 
 	if len(rows) <= 1 {
-		return nil, fmt.Errorf("participants sheet is empty or only contains header")
+		return nil, nil, fmt.Errorf("%s sheet is empty or only contains header", sheet)
+	}
+
+	// Columns are located by header name, see resolveColumns
+	header, err := resolveColumns(sheet+" sheet", "participants", rows[0], participantFields, mapping.Participants)
+	if err != nil {
+		return nil, nil, err
+	}
+	attributes := attributeColumns(rows[0], header)
+
+	// Winners saved by previous events feed the history-based weighting
+	history, rowErrs, err := loadWinningHistory(f, mapping.Sheets.Winners)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var participants []model.Participant
@@ -111,24 +165,224 @@ func LoadParticipantsFromExcel(filePath string) ([]model.Participant, error) {
 			continue // Skip incomplete rows
 		}
 
-		var id int
-		if _, err := fmt.Sscanf(row[0], "%d", &id); err != nil {
-			fmt.Printf("warning: skipping row %d, invalid ID: %v\n", i+2, err)
+		participant, warnings, err := parseParticipant(row, header, attributes)
+		if err != nil {
+			fmt.Printf("warning: %v\n", rowErrs.add(sheet, i+2, true, err))
 			continue
 		}
-
-		participant := model.Participant{
-			ID:             id,
-			Name:           row[1],
-			WinningHistory: []model.WinningRecord{}, // Will be loaded separately if needed
+		for _, w := range warnings {
+			fmt.Printf("warning: %v\n", rowErrs.add(sheet, i+2, false, w))
+		}
+		participant.WinningHistory = history[participant.ID]
+		if participant.WinningHistory == nil {
+			participant.WinningHistory = []model.WinningRecord{}
 		}
 		participants = append(participants, participant)
 	}
 
 	fmt.Printf("Successfully loaded %d participants from Excel file [%s]\n", len(participants), filePath)
-	return participants, nil
+	return participants, rowErrs, nil
+}
+
+// parseParticipant builds a participant from a roster row whose columns were
+// resolved against participantFields, keeping the non-empty values of the
+// attribute columns. Invalid optional values are reported as warnings and left
+// unset, while an invalid ID rejects the row.
+func parseParticipant(row []string, header map[string]int, attributes map[int]string) (model.Participant, []error, error) {
+	var p model.Participant
+	if _, err := fmt.Sscanf(cellAt(row, header, "id"), "%d", &p.ID); err != nil {
+		return p, nil, fmt.Errorf("invalid ID: %w", err)
+	}
+	p.Name = cellAt(row, header, "name")
+	p.Department = cellAt(row, header, "department")
+	p.Email = cellAt(row, header, "email")
+	for i, name := range attributes {
+		if i >= len(row) {
+			continue
+		}
+		if v := strings.TrimSpace(row[i]); v != "" {
+			if p.Attributes == nil {
+				p.Attributes = make(map[string]string)
+			}
+			p.Attributes[name] = v
+		}
+	}
+
+	var warnings []error
+	if v := cellAt(row, header, "tenure"); v != "" {
+		if _, err := fmt.Sscanf(v, "%g", &p.Tenure); err != nil {
+			warnings = append(warnings, fmt.Errorf("invalid Tenure: %w", err))
+		}
+	}
+	if v := cellAt(row, header, "attendance"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &p.Attendance); err != nil {
+			warnings = append(warnings, fmt.Errorf("invalid Attendance: %w", err))
+		}
+	}
+	if v := cellAt(row, header, "weight"); v != "" {
+		if _, err := fmt.Sscanf(v, "%g", &p.Weight); err != nil || p.Weight < 0 {
+			p.Weight = 0
+			warnings = append(warnings, fmt.Errorf("invalid Weight %q", v))
+		}
+	}
+	return p, warnings, nil
+}
+
+// loadWinningHistory reads the winners sheet written by SaveWinnersToExcel.
+// A workbook without that sheet has no history.
+func loadWinningHistory(f *excelize.File, sheet string) (map[int][]model.WinningRecord, rowErrors, error) {
+	if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+		return nil, nil, nil
+	}
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s sheet: %w", sheet, err)
+	}
+	history, rowErrs := winningHistoryFromRows(sheet, rows)
+	return history, rowErrs, nil
+}
+
+// winningHistoryFromRows groups winner rows, laid out like the Winners sheet, by
+// winner ID. The year comes from the Event Year column, or from the Draw Time for
+// sheets written before that column existed. Forfeited rows are skipped, and a
+// winner saved more than once for the same prize and year counts once.
+func winningHistoryFromRows(source string, rows [][]string) (map[int][]model.WinningRecord, rowErrors) {
+	if len(rows) <= 1 {
+		return nil, nil
+	}
+
+	header := headerColumns(rows[0])
+	type recordKey struct {
+		id    int
+		year  int
+		prize string
+	}
+	seen := make(map[recordKey]bool)
+	history := make(map[int][]model.WinningRecord)
+	var rowErrs rowErrors
+	for i, row := range rows[1:] {
+		if status := cellAt(row, header, "status"); status != "" && !strings.EqualFold(status, "Won") {
+			continue
+		}
+
+		var id, level int
+		if _, err := fmt.Sscanf(cellAt(row, header, "winner id"), "%d", &id); err != nil {
+			fmt.Printf("warning: %v\n", rowErrs.add(source, i+2, true, fmt.Errorf("invalid Winner ID: %w", err)))
+			continue
+		}
+		if _, err := fmt.Sscanf(cellAt(row, header, "prize level"), "%d", &level); err != nil {
+			fmt.Printf("warning: %v\n", rowErrs.add(source, i+2, true, fmt.Errorf("invalid Prize Level: %w", err)))
+			continue
+		}
+		year, err := winnerYear(row, header)
+		if err != nil {
+			fmt.Printf("warning: %v\n", rowErrs.add(source, i+2, true, err))
+			continue
+		}
+
+		key := recordKey{id: id, year: year, prize: cellAt(row, header, "prize name")}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		history[id] = append(history[id], model.WinningRecord{ParticipantID: id, Year: year, PrizeLevel: level})
+	}
+	return history, rowErrs
+}
+
+// winnerYear returns the event year of a Winners row, falling back to the year of its Draw Time
+func winnerYear(row []string, header map[string]int) (int, error) {
+	if v := cellAt(row, header, "event year"); v != "" {
+		var year int
+		if _, err := fmt.Sscanf(v, "%d", &year); err != nil || year <= 0 {
+			return 0, fmt.Errorf("invalid Event Year %q", v)
+		}
+		return year, nil
+	}
+
+	v := cellAt(row, header, "draw time")
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02", "2006/1/2 15:04:05", "2006/1/2"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.Year(), nil
+		}
+	}
+	// Dates typed into Excel by hand may come back as a serial number
+	if serial, err := strconv.ParseFloat(v, 64); err == nil {
+		if t, err := excelize.ExcelDateToTime(serial, false); err == nil {
+			return t.Year(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid Draw Time %q", v)
+}
+
+// headerColumns maps lower-cased header names to their column index
+func headerColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return columns
+}
+
+// cellAt returns the trimmed cell under the named header, or "" if absent
+func cellAt(row []string, header map[string]int, name string) string {
+	i, ok := header[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// splitRules splits a cell holding several rules separated by ";" (or the full-width "；")
+func splitRules(cell string) []string {
+	var rules []string
+	for _, rule := range strings.FieldsFunc(cell, func(r rune) bool { return r == ';' || r == '；' }) {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// excelSource reads prizes and participants from a workbook and keeps the
+// winners in its Winners sheet, where the next event picks them up as history
+type excelSource struct {
+	path    string
+	mapping config.MappingConfig
+	rowErrs []RowError
+}
+
+func openExcelSource(cfg config.DataSourceConfig) (Source, error) {
+	if cfg.Excel.Path == "" {
+		return nil, errors.New("excel path is not configured")
+	}
+	return &excelSource{path: cfg.Excel.Path, mapping: mappingFor(cfg)}, nil
+}
+
+func (s *excelSource) LoadPrizes() ([]model.Prize, error) {
+	prizes, rowErrs, err := loadPrizesFromExcel(s.path, s.mapping)
+	s.rowErrs = append(s.rowErrs, rowErrs...)
+	return prizes, err
+}
+
+func (s *excelSource) LoadParticipants() ([]model.Participant, error) {
+	participants, rowErrs, err := loadParticipantsFromExcel(s.path, s.mapping)
+	s.rowErrs = append(s.rowErrs, rowErrs...)
+	return participants, err
 }
 
+// RowErrors returns the rows skipped or partly ignored by the loads so far
+func (s *excelSource) RowErrors() []RowError { return s.rowErrs }
+
+func (s *excelSource) SaveWinners(session string, winners []Winner) error {
+	if session == "" {
+		return errors.New("session is required to replace winners")
+	}
+	return saveWinnersToExcel(s.path, s.mapping.Sheets.Winners, session, withSession(winners, session))
+}
+
+func (s *excelSource) Close() error { return nil }
+
 // Winner represents a lottery winner for Excel export
 type Winner struct {
 	DrawTime   time.Time
@@ -136,10 +390,83 @@ type Winner struct {
 	WinnerID   int
 	WinnerName string
 	PrizeLevel int
+	Status     string // "Won", or "Forfeited" when the winner was absent and redrawn
+	EventYear  int    // Year the event belongs to, which may differ from DrawTime's year
+	Session    string // Identifies the lottery session that drew the winner
+}
+
+// winnersHeader is the header row of the Winners sheet; new columns are only ever appended
+var winnersHeader = []interface{}{"Draw Time", "Prize Name", "Winner ID", "Winner Name", "Prize Level", "Status", "Event Year", "Session"}
+
+// row returns the winner as a Winners sheet row, in winnersHeader order
+func (w Winner) row() []interface{} {
+	return []interface{}{
+		w.DrawTime.Format("2006-01-02 15:04:05"),
+		w.PrizeName,
+		w.WinnerID,
+		w.WinnerName,
+		w.PrizeLevel,
+		w.Status,
+		w.EventYear,
+		w.Session,
+	}
 }
 
-// SaveWinnersToExcel saves winners to Excel file
+// SaveWinnersToExcel appends winners to the Winners sheet of the Excel file
 func SaveWinnersToExcel(filePath string, winners []Winner) error {
+	if err := saveWinnersToExcel(filePath, SheetWinners, "", winners); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully saved %d winners to Excel file [%s]\n", len(winners), filePath)
+	return nil
+}
+
+// ReplaceSessionWinnersInExcel replaces the Winners rows of one session with
+// winners, along with the rows of earlier sessions of the same event year (see
+// replacesRow), and keeps the rest. It is meant to be called after every draw:
+// saving the same winners again leaves the sheet unchanged, and rows removed by
+// an undo or reset disappear on the next save.
+func ReplaceSessionWinnersInExcel(filePath, session string, winners []Winner) error {
+	if session == "" {
+		return errors.New("session is required to replace winners")
+	}
+	return saveWinnersToExcel(filePath, SheetWinners, session, withSession(winners, session))
+}
+
+// withSession returns a copy of winners with their Session set
+func withSession(winners []Winner, session string) []Winner {
+	stamped := make([]Winner, len(winners))
+	for i, w := range winners {
+		w.Session = session
+		stamped[i] = w
+	}
+	return stamped
+}
+
+// replacesRow returns a check for saved winners rows that session replaces when
+// it saves winners: its own rows, and the rows of other sessions of the same
+// event year, so re-running an event with a new seed leaves no trace of the
+// discarded run. Rows without a session were not written by a session and stay.
+func replacesRow(session string, winners []Winner) func(row []string) bool {
+	eventYear := ""
+	for _, w := range winners {
+		if w.EventYear != 0 {
+			eventYear = strconv.Itoa(w.EventYear)
+			break
+		}
+	}
+	sessionCol, yearCol := len(winnersHeader)-1, len(winnersHeader)-2
+	return func(row []string) bool {
+		if sessionCol >= len(row) || row[sessionCol] == "" {
+			return false
+		}
+		return row[sessionCol] == session || (eventYear != "" && row[yearCol] == eventYear)
+	}
+}
+
+// saveWinnersToExcel removes the rows session replaces (unless session is empty)
+// from the winners sheet, creating it if needed, and appends winners
+func saveWinnersToExcel(filePath, sheet, session string, winners []Winner) error {
 	f, err := excelize.OpenFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open Excel file: %w", err)
@@ -150,33 +477,48 @@ func SaveWinnersToExcel(filePath string, winners []Winner) error {
 		}
 	}()
 
+	// Rosters exported from other systems have no winners sheet yet
+	if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return fmt.Errorf("failed to create %s sheet: %w", sheet, err)
+		}
+	}
+
 	// Get existing rows to append new winners
-	rows, err := f.GetRows(SheetWinners)
+	rows, err := f.GetRows(sheet)
 	if err != nil {
-		return fmt.Errorf("failed to read Winners sheet: %w", err)
+		return fmt.Errorf("failed to read %s sheet: %w", sheet, err)
 	}
 
-	startRow := len(rows) + 1
-	if len(rows) == 0 {
-		// If sheet is empty, add header first
-		header := []interface{}{"Draw Time", "Prize Name", "Winner ID", "Winner Name", "Prize Level"}
-		if err := f.SetSheetRow(SheetWinners, "A1", &header); err != nil {
+	if len(rows) == 0 || len(rows[0]) < len(winnersHeader) {
+		// Add the header to an empty sheet, or the newer columns to an older one
+		if err := f.SetSheetRow(sheet, "A1", &winnersHeader); err != nil {
 			return fmt.Errorf("failed to write header: %w", err)
 		}
-		startRow = 2
+		if len(rows) == 0 {
+			rows = [][]string{{}}
+		}
+	}
+
+	// Remove the replaced rows, bottom-up so row numbers stay valid
+	if session != "" {
+		replaced := replacesRow(session, winners)
+		for i := len(rows) - 1; i >= 1; i-- {
+			if replaced(rows[i]) {
+				if err := f.RemoveRow(sheet, i+1); err != nil {
+					return fmt.Errorf("failed to remove winner row %d: %w", i+1, err)
+				}
+				rows = append(rows[:i], rows[i+1:]...)
+			}
+		}
 	}
 
 	// Write winners
+	startRow := len(rows) + 1
 	for i, winner := range winners {
-		row := []interface{}{
-			winner.DrawTime.Format("2006-01-02 15:04:05"),
-			winner.PrizeName,
-			winner.WinnerID,
-			winner.WinnerName,
-			winner.PrizeLevel,
-		}
+		row := winner.row()
 		cell := fmt.Sprintf("A%d", startRow+i)
-		if err := f.SetSheetRow(SheetWinners, cell, &row); err != nil {
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
 			return fmt.Errorf("failed to write winner row %d: %w", i, err)
 		}
 	}
@@ -185,8 +527,6 @@ func SaveWinnersToExcel(filePath string, winners []Winner) error {
 	if err := f.Save(); err != nil {
 		return fmt.Errorf("failed to save Excel file: %w", err)
 	}
-
-	fmt.Printf("Successfully saved %d winners to Excel file [%s]\n", len(winners), filePath)
 	return nil
 }
 
@@ -261,7 +601,6 @@ func CreateExcelTemplate(filePath string) error {
 	}
 
 	// Winners header
-	winnersHeader := []interface{}{"Draw Time", "Prize Name", "Winner ID", "Winner Name", "Prize Level"}
 	if err := f.SetSheetRow(SheetWinners, "A1", &winnersHeader); err != nil {
 		return fmt.Errorf("failed to write winners header: %w", err)
 	}
@@ -1,64 +1,256 @@
 package datasource
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
-	"strconv"
+	"path/filepath"
+	"sort"
+	"sync"
 
 	"github.com/palemoky/lucky-day/internal/config"
 	"github.com/palemoky/lucky-day/internal/model"
 )
 
+// DefaultWinnersFile 不在数据源内保存中奖结果时使用的 CSV 文件名
+const DefaultWinnersFile = "lottery_winners.csv"
+
+// Source 数据源，提供奖品和参与者，并保存中奖结果
+type Source interface {
+	LoadPrizes() ([]model.Prize, error)
+	LoadParticipants() ([]model.Participant, error)
+	// SaveWinners 用 winners 替换 session 之前保存的中奖结果，每次抽奖后都会调用，
+	// 因此必须是幂等的：重复保存不会产生重复记录，撤销的结果会被删除。
+	// 同一活动年份其他场次保存的结果同样被替换，重新抽奖后不会留下被放弃的那一场
+	SaveWinners(session string, winners []Winner) error
+	Close() error
+}
+
+// Factory 根据配置打开一种数据源
+type Factory func(cfg config.DataSourceConfig) (Source, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"csv":   openCSVSource,
+		"excel": openExcelSource,
+		"db":    openDBSource,
+	}
+)
+
+// Register 注册一种数据源，typ 对应配置中的 datasource.type，重复注册会覆盖之前的实现
+func Register(typ string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[typ] = factory
+}
+
+// Types 返回所有已注册的数据源类型，按名称排序
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	types := make([]string, 0, len(registry))
+	for typ := range registry {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Open 按 cfg.Type 打开数据源，用完后需调用 Close
+func Open(cfg config.DataSourceConfig) (Source, error) {
+	registryMu.RLock()
+	factory, ok := registry[cfg.Type]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的数据源类型: %s，可选: %v", cfg.Type, Types())
+	}
+	return factory(cfg)
+}
+
 // LoadParticipants 统一入口函数
 func LoadParticipants(cfg config.DataSourceConfig) ([]model.Participant, error) {
-	switch cfg.Type {
-	case "csv":
-		fmt.Println("数据源: CSV 文件")
-		return loadParticipantsFromCSV(cfg.CSV.Path)
-	case "excel":
-		fmt.Println("数据源: Excel 文件")
-		return LoadParticipantsFromExcel(cfg.Excel.Path)
-	case "db":
-		fmt.Printf("数据源: 数据库 (%s)\n", cfg.Database.Driver)
-		return loadParticipantsFromDB(cfg.Database)
-	default:
-		return nil, fmt.Errorf("未知的数据源类型: %s", cfg.Type)
-	}
-}
-
-// loadParticipantsFromCSV
-func loadParticipantsFromCSV(filePath string) ([]model.Participant, error) {
-	file, err := os.Open(filePath)
+	src, err := Open(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("无法打开 CSV 文件: %w", err)
+		return nil, err
+	}
+	defer func() { _ = src.Close() }()
+	return src.LoadParticipants()
+}
+
+// configDir 返回 config.yml 所在的目录，用于读取其中的奖品配置
+func configDir(cfg config.DataSourceConfig) string {
+	if cfg.ConfigDir == "" {
+		return "."
+	}
+	return cfg.ConfigDir
+}
+
+// csvSource 从 CSV 文件读取参与者，奖品读取自 config.yml，中奖结果保存到另一个 CSV 文件
+type csvSource struct {
+	cfg     config.DataSourceConfig
+	rowErrs []RowError
+}
+
+func openCSVSource(cfg config.DataSourceConfig) (Source, error) {
+	if cfg.CSV.Path == "" {
+		return nil, fmt.Errorf("未配置 CSV 文件路径")
+	}
+	return &csvSource{cfg: cfg}, nil
+}
+
+func (s *csvSource) LoadPrizes() ([]model.Prize, error) {
+	return config.LoadPrizes(configDir(s.cfg))
+}
+
+func (s *csvSource) LoadParticipants() ([]model.Participant, error) {
+	participants, rowErrs, err := loadParticipantsFromCSV(s.cfg.CSV.Path, s.cfg.Mapping.Participants)
+	if err != nil {
+		return nil, err
+	}
+	s.rowErrs = append(s.rowErrs, rowErrs...)
+
+	// 往年的中奖记录读取自 csv.history，未配置时读取本数据源保存的中奖结果
+	historyPath := s.cfg.CSV.History
+	if historyPath == "" {
+		historyPath = s.winnersPath()
+	}
+	history, rowErrs, err := loadWinningHistoryFromCSV(historyPath)
+	if err != nil && (s.cfg.CSV.History != "" || !errors.Is(err, os.ErrNotExist)) {
+		return nil, fmt.Errorf("无法读取中奖历史: %w", err)
+	}
+	s.rowErrs = append(s.rowErrs, rowErrs...)
+	for i := range participants {
+		if records := history[participants[i].ID]; records != nil {
+			participants[i].WinningHistory = records
+		}
+	}
+	return participants, nil
+}
+
+// SaveWinners 保存到 csv.winners，未配置时保存到名单所在目录的 lottery_winners.csv
+func (s *csvSource) SaveWinners(session string, winners []Winner) error {
+	return ReplaceSessionWinnersInCSV(s.winnersPath(), session, winners)
+}
+
+func (s *csvSource) Close() error { return nil }
+
+// RowErrors 返回已加载的名单和中奖历史中被跳过或部分忽略的行
+func (s *csvSource) RowErrors() []RowError { return s.rowErrs }
+
+// winnersPath 返回中奖结果保存的 CSV 文件
+func (s *csvSource) winnersPath() string {
+	if s.cfg.CSV.Winners != "" {
+		return s.cfg.CSV.Winners
 	}
-	defer func() { _ = file.Close() }() // Ignore close error
+	return filepath.Join(filepath.Dir(s.cfg.CSV.Path), DefaultWinnersFile)
+}
 
-	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = -1
-	records, err := reader.ReadAll()
+// loadParticipantsFromCSV 读取参与者名单，按表头识别列，mapping 为字段到表头的映射：
+// 未配置映射且找不到 ID、Name 时取前两列，Department、Email、Tenure、Attendance、Weight 为可选列，
+// 其他列保存为参与者的扩展属性。同时返回无法解析的行
+func loadParticipantsFromCSV(filePath string, mapping map[string]string) ([]model.Participant, []RowError, error) {
+	records, err := readCSV(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("无法读取 CSV 内容: %w", err)
+		return nil, nil, fmt.Errorf("无法读取 CSV 文件: %w", err)
 	}
 
 	if len(records) <= 1 {
-		return []model.Participant{}, nil
+		return []model.Participant{}, nil, nil
 	}
 
-	var participants []model.Participant
-	for _, record := range records[1:] {
+	source := filepath.Base(filePath)
+	header, err := resolveColumns(source, "participants", records[0], participantFields, mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	attributes := attributeColumns(records[0], header)
+	var (
+		participants []model.Participant
+		rowErrs      rowErrors
+	)
+	for i, record := range records[1:] {
 		if len(record) < 2 {
 			continue
 		}
-		id, _ := strconv.Atoi(record[0])
-		participant := model.Participant{
-			ID:             id,
-			Name:           record[1],
-			WinningHistory: []model.WinningRecord{}, // CSV 简化处理
+		participant, warnings, err := parseParticipant(record, header, attributes)
+		if err != nil {
+			fmt.Printf("警告: %v\n", rowErrs.add(source, i+2, true, err))
+			continue
+		}
+		for _, w := range warnings {
+			fmt.Printf("警告: %v\n", rowErrs.add(source, i+2, false, w))
 		}
+		participant.WinningHistory = []model.WinningRecord{}
 		participants = append(participants, participant)
 	}
 	fmt.Printf("成功从 CSV 文件 [%s] 加载了 %d 名参与者。\n", filePath, len(participants))
-	return participants, nil
+	return participants, rowErrs, nil
+}
+
+// loadWinningHistoryFromCSV 读取中奖历史 CSV，列与 Excel 的 Winners 表相同，
+// 至少需要 Winner ID、Prize Level 以及 Event Year 或 Draw Time
+func loadWinningHistoryFromCSV(filePath string) (map[int][]model.WinningRecord, []RowError, error) {
+	records, err := readCSV(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	history, rowErrs := winningHistoryFromRows(filepath.Base(filePath), records)
+	return history, rowErrs, nil
+}
+
+// checkInSource 现场签到的数据源：参与者是签到的人，奖品来自配置的数据源。
+// 签到分配的 ID 与名单无关，中奖结果单独保存到 CSV 文件，不写回配置的数据源。
+type checkInSource struct {
+	prizes       Source
+	participants []model.Participant
+	winnersPath  string
+}
+
+// NewCheckInSource 创建签到数据源，prizes 提供奖品并在 Close 时一并关闭，
+// participants 为签到的参与者，中奖结果保存到 winnersPath
+func NewCheckInSource(prizes Source, participants []model.Participant, winnersPath string) Source {
+	return &checkInSource{prizes: prizes, participants: participants, winnersPath: winnersPath}
+}
+
+func (s *checkInSource) LoadPrizes() ([]model.Prize, error) {
+	return s.prizes.LoadPrizes()
+}
+
+func (s *checkInSource) LoadParticipants() ([]model.Participant, error) {
+	return s.participants, nil
+}
+
+func (s *checkInSource) SaveWinners(session string, winners []Winner) error {
+	return ReplaceSessionWinnersInCSV(s.winnersPath, session, winners)
+}
+
+func (s *checkInSource) Close() error {
+	return s.prizes.Close()
+}
+
+// RowErrors 返回奖品数据源报告的无法解析的行
+func (s *checkInSource) RowErrors() []RowError {
+	return RowErrors(s.prizes)
+}
+
+// presentSource 按名单核对签到的数据源：参与者是名单中已签到的人，ID 与名单一致，
+// 因此奖品和中奖结果仍使用名单所在的数据源
+type presentSource struct {
+	Source
+	present []model.Participant
+}
+
+// NewPresentSource 创建只包含已签到参与者的数据源，其余操作交给 roster，Close 时一并关闭
+func NewPresentSource(roster Source, present []model.Participant) Source {
+	return &presentSource{Source: roster, present: present}
+}
+
+func (s *presentSource) LoadParticipants() ([]model.Participant, error) {
+	return s.present, nil
+}
+
+// RowErrors 返回名单数据源报告的无法解析的行
+func (s *presentSource) RowErrors() []RowError {
+	return RowErrors(s.Source)
 }
@@ -0,0 +1,165 @@
+package datasource
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/palemoky/lucky-day/internal/config"
+)
+
+// fieldSpec describes a field read from an imported sheet
+type fieldSpec struct {
+	name     string   // Field name used in the mapping section of config.yml
+	headers  []string // Default headers, lower case
+	position int      // Column used when the sheet has neither the header nor a mapping, -1 for optional fields
+}
+
+// participantFields are the fields of a roster sheet
+var participantFields = []fieldSpec{
+	{name: "id", headers: []string{"id"}, position: 0},
+	{name: "name", headers: []string{"name"}, position: 1},
+	{name: "department", headers: []string{"department"}, position: -1},
+	{name: "email", headers: []string{"email"}, position: -1},
+	{name: "tenure", headers: []string{"tenure"}, position: -1},
+	{name: "attendance", headers: []string{"attendance"}, position: -1},
+	{name: "weight", headers: []string{"weight"}, position: -1},
+}
+
+// prizeFields are the fields of a Prizes sheet; the positions match the template
+var prizeFields = []fieldSpec{
+	{name: "id", headers: []string{"id"}, position: 0},
+	{name: "name", headers: []string{"name (cn)", "name"}, position: 1},
+	{name: "count", headers: []string{"count"}, position: 3},
+	{name: "level", headers: []string{"level"}, position: 4},
+	{name: "probability", headers: []string{"probability"}, position: 5},
+	{name: "quotas", headers: []string{"quotas"}, position: -1},
+	{name: "eligibility", headers: []string{"eligibility"}, position: -1},
+	{name: "batch_size", headers: []string{"batchsize", "batch size", "batch_size"}, position: -1},
+}
+
+// resolveColumns maps each field to its column in the header row. A field is
+// found by its mapped header, or by one of its default headers when unmapped.
+// Sheets without any mapping fall back to the template positions for missing
+// required fields, so older workbooks keep loading; once a sheet is mapped,
+// every required field must be found by header. source names the sheet or file
+// in errors, and section is the mapping section the fields are configured in.
+func resolveColumns(source, section string, header []string, fields []fieldSpec, mapping map[string]string) (map[string]int, error) {
+	columns := headerColumns(header)
+
+	known := make([]string, len(fields))
+	for i, f := range fields {
+		known[i] = f.name
+	}
+	for _, name := range slices.Sorted(maps.Keys(mapping)) {
+		if !slices.Contains(known, strings.ToLower(name)) {
+			return nil, fmt.Errorf("unknown field %q in mapping.%s, expected one of: %s", name, section, strings.Join(known, ", "))
+		}
+	}
+	mapped := make(map[string]string, len(mapping))
+	for name, h := range mapping {
+		mapped[strings.ToLower(name)] = h
+	}
+
+	resolved := make(map[string]int, len(fields))
+	var missing []string
+	for _, f := range fields {
+		headers := f.headers
+		if h, ok := mapped[f.name]; ok {
+			headers = []string{strings.ToLower(strings.TrimSpace(h))}
+		}
+		found := false
+		for _, h := range headers {
+			if i, ok := columns[h]; ok {
+				resolved[f.name] = i
+				found = true
+				break
+			}
+		}
+		switch {
+		case found || f.position < 0:
+		case len(mapping) == 0:
+			resolved[f.name] = f.position
+		default:
+			missing = append(missing, fmt.Sprintf("%q (%s)", headers[0], f.name))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s is missing required headers: %s", source, strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// attributeColumns returns the columns of header that no field was resolved
+// to, keyed by column and holding the trimmed header. Their values are kept as
+// the participant's attributes.
+func attributeColumns(header []string, resolved map[string]int) map[int]string {
+	used := make(map[int]bool, len(resolved))
+	for _, i := range resolved {
+		used[i] = true
+	}
+	columns := make(map[int]string)
+	for i, h := range header {
+		if h = strings.TrimSpace(h); h != "" && !used[i] {
+			columns[i] = h
+		}
+	}
+	return columns
+}
+
+// sheetName returns the configured name of a sheet, or its default name
+func sheetName(configured, fallback string) string {
+	if configured != "" {
+		return configured
+	}
+	return fallback
+}
+
+// mappingFor returns the mapping of the Excel and CSV loaders, with sheet names defaulted
+func mappingFor(cfg config.DataSourceConfig) config.MappingConfig {
+	m := cfg.Mapping
+	m.Sheets.Prizes = sheetName(m.Sheets.Prizes, SheetPrizes)
+	m.Sheets.Participants = sheetName(m.Sheets.Participants, SheetParticipants)
+	m.Sheets.Winners = sheetName(m.Sheets.Winners, SheetWinners)
+	return m
+}
+
+// RowError describes a row that a loader could not fully parse
+type RowError struct {
+	Source  string // Sheet or file name
+	Row     int    // 1-based row number, the header being row 1
+	Skipped bool   // The whole row was skipped; otherwise only invalid optional values were ignored
+	Err     error
+}
+
+func (e RowError) Error() string {
+	if e.Skipped {
+		return fmt.Sprintf("%s row %d skipped: %v", e.Source, e.Row, e.Err)
+	}
+	return fmt.Sprintf("%s row %d: %v", e.Source, e.Row, e.Err)
+}
+
+// RowErrorReporter is implemented by sources that can report the rows they
+// skipped or partly ignored while loading
+type RowErrorReporter interface {
+	RowErrors() []RowError
+}
+
+// RowErrors returns the row errors of src, or nil if it does not report them
+func RowErrors(src Source) []RowError {
+	if r, ok := src.(RowErrorReporter); ok {
+		return r.RowErrors()
+	}
+	return nil
+}
+
+// rowErrors collects the row errors of a loader
+type rowErrors []RowError
+
+// add records a row error and returns it, so the loader can print it as a warning
+func (r *rowErrors) add(source string, row int, skipped bool, err error) RowError {
+	e := RowError{Source: source, Row: row, Skipped: skipped, Err: err}
+	*r = append(*r, e)
+	return e
+}
@@ -19,8 +19,10 @@ var translations = map[Language]map[string]string{
 		// Mode Selection
 		"mode.select":      "请选择抽奖模式",
 		"mode.excel":       "从 Excel 文件导入",
+		"mode.csv":         "从 CSV 文件导入",
 		"mode.qr":          "二维码签到模式",
 		"mode.db":          "从数据库加载",
+		"mode.resume":      "恢复上次未完成的抽奖",
 		"mode.instruction": "使用 ↑/↓ 选择，回车确认，q 退出",
 
 		// Prize Selection
@@ -35,6 +37,17 @@ var translations = map[Language]map[string]string{
 		"draw.instruction": "按任意键停止",
 		"draw.rolling":     "滚动中",
 
+		// Fairness
+		"fairness.commitment":    "抽奖承诺（请在抽奖前公布）",
+		"fairness.reveal_saved":  "种子及抽奖记录已公开至",
+		"fairness.reveal_failed": "保存验证材料失败",
+		"fairness.reveal_held":   "还有奖项没有抽完，种子继续保密，下次启动时可以恢复抽奖",
+		"fairness.event_year":    "活动年份（用于计算往年中奖权重）",
+		"journal.resumed":        "已从会话日志恢复抽奖",
+		"journal.resume_failed":  "恢复会话失败",
+		"journal.saved":          "会话日志",
+		"journal.failed":         "创建会话日志失败",
+
 		// Winners
 		"winner.title":        "恭喜中奖！",
 		"winner.instruction":  "按任意键返回奖项选择",
@@ -42,9 +55,25 @@ var translations = map[Language]map[string]string{
 		"winner.no_winners":   "暂无中奖者",
 		"winner.prize":        "奖项",
 		"winner.name":         "姓名",
-		"winner.save_success": "中奖名单已保存到 Excel",
+		"winner.save_success": "中奖名单已保存",
 		"winner.save_failed":  "保存中奖名单失败",
 
+		// Validation Report
+		"validation.title":               "抽奖前检查",
+		"validation.summary":             "检查了名单和奖品",
+		"validation.errors":              "个错误",
+		"validation.warnings":            "个警告",
+		"validation.dedupe":              "继续时，重复的 ID 只保留第一条记录",
+		"validation.instruction":         "↑/↓ 滚动，Enter 继续抽奖，q 放弃",
+		"validation.aborted":             "已放弃抽奖，请修正名单后重新运行",
+		"validation.kind.duplicate_id":   "重复的 ID",
+		"validation.kind.duplicate_name": "重复的姓名",
+		"validation.kind.blank_name":     "姓名为空",
+		"validation.kind.skipped_row":    "跳过的行",
+		"validation.kind.ignored_value":  "忽略的无效值",
+		"validation.kind.zero_count":     "奖品数量为零",
+		"validation.kind.invalid_level":  "奖品等级无效",
+
 		// QR Check-in
 		"qr.title":              "二维码签到",
 		"qr.instruction":        "请用手机扫描二维码进行签到",
@@ -60,12 +89,24 @@ var translations = map[Language]map[string]string{
 		"qr.name_required":      "请输入姓名",
 		"qr.name_placeholder":   "请输入您的姓名",
 		"qr.dept_placeholder":   "部门（可选）",
+		"qr.id_placeholder":     "请输入您的工号",
+		"qr.code_placeholder":   "请输入您的签到码",
 		"qr.submit":             "提交签到",
 		"qr.ready":              "二维码签到已准备就绪！",
 		"qr.hint":               "请在其他设备上打开二维码图片，让参与者扫码签到",
 		"qr.press_enter":        "签到完成后按 Enter 键开始抽奖",
 		"qr.no_participants":    "没有人签到",
 		"qr.total_participants": "共有签到人数",
+		"qr.pause":              "按 p 暂停签到",
+		"qr.resume":             "按 p 恢复签到",
+		"qr.paused":             "签到已暂停",
+		"qr.duplicates":         "重复提交",
+		"qr.elapsed":            "签到时长",
+		"qr.recent":             "最新签到",
+		"qr.save_failed":        "保存签到名单失败",
+		"qr.too_small":          "窗口太小，二维码可能无法扫描，请放大终端窗口",
+		"qr.select_address":     "请选择签到二维码使用的网络地址（手机需连接同一网络）",
+		"qr.no_lan":             "未找到局域网地址，二维码使用 localhost，其他设备无法访问；可在 config.yml 的 checkin 中配置 host 或 base_url",
 
 		// Data Source
 		"data.loading":         "正在加载数据...",
@@ -108,8 +149,10 @@ var translations = map[Language]map[string]string{
 		// Mode Selection
 		"mode.select":      "Select Lottery Mode",
 		"mode.excel":       "Load from Excel File",
+		"mode.csv":         "Load from CSV File",
 		"mode.qr":          "QR Code Check-in Mode",
 		"mode.db":          "Load from Database",
+		"mode.resume":      "Resume Previous Session",
 		"mode.instruction": "Use ↑/↓ to select, Enter to confirm, q to quit",
 
 		// Prize Selection
@@ -124,6 +167,17 @@ var translations = map[Language]map[string]string{
 		"draw.instruction": "Press any key to stop",
 		"draw.rolling":     "Rolling",
 
+		// Fairness
+		"fairness.commitment":    "Draw commitment (publish before the draw)",
+		"fairness.reveal_saved":  "Seed and draw log revealed in",
+		"fairness.reveal_failed": "Failed to save reveal file",
+		"fairness.reveal_held":   "Some prizes are not drawn yet, so the seed stays secret; resume the session at the next start",
+		"fairness.event_year":    "Event year (for past-winner weighting)",
+		"journal.resumed":        "Session resumed from journal",
+		"journal.resume_failed":  "Failed to resume session",
+		"journal.saved":          "Session journal",
+		"journal.failed":         "Failed to create session journal",
+
 		// Winners
 		"winner.title":        "Congratulations!",
 		"winner.instruction":  "Press any key to return",
@@ -131,9 +185,25 @@ var translations = map[Language]map[string]string{
 		"winner.no_winners":   "No winners yet",
 		"winner.prize":        "Prize",
 		"winner.name":         "Name",
-		"winner.save_success": "Winners saved to Excel",
+		"winner.save_success": "Winners saved",
 		"winner.save_failed":  "Failed to save winners",
 
+		// Validation Report
+		"validation.title":               "Pre-draw Check",
+		"validation.summary":             "Checked the roster and prizes",
+		"validation.errors":              "error(s)",
+		"validation.warnings":            "warning(s)",
+		"validation.dedupe":              "If you continue, only the first record of each duplicate ID is kept",
+		"validation.instruction":         "↑/↓ to scroll, Enter to continue, q to abort",
+		"validation.aborted":             "Draw aborted, fix the roster and run again",
+		"validation.kind.duplicate_id":   "Duplicate ID",
+		"validation.kind.duplicate_name": "Duplicate name",
+		"validation.kind.blank_name":     "Blank name",
+		"validation.kind.skipped_row":    "Skipped row",
+		"validation.kind.ignored_value":  "Ignored invalid value",
+		"validation.kind.zero_count":     "Prize count is zero",
+		"validation.kind.invalid_level":  "Invalid prize level",
+
 		// QR Check-in
 		"qr.title":              "QR Code Check-in",
 		"qr.instruction":        "Scan QR code with your phone to check in",
@@ -149,12 +219,24 @@ var translations = map[Language]map[string]string{
 		"qr.name_required":      "Name is required",
 		"qr.name_placeholder":   "Enter your name",
 		"qr.dept_placeholder":   "Department (optional)",
+		"qr.id_placeholder":     "Your employee ID",
+		"qr.code_placeholder":   "Your check-in code",
 		"qr.submit":             "Submit Check-in",
 		"qr.ready":              "QR Code check-in is ready!",
 		"qr.hint":               "Open the QR code image on another device for participants to scan",
 		"qr.press_enter":        "Press Enter to start lottery after check-in is complete",
 		"qr.no_participants":    "No participants checked in",
 		"qr.total_participants": "Total participants",
+		"qr.pause":              "Press p to pause check-in",
+		"qr.resume":             "Press p to resume check-in",
+		"qr.paused":             "Check-in paused",
+		"qr.duplicates":         "Repeat submissions",
+		"qr.elapsed":            "Elapsed",
+		"qr.recent":             "Latest check-ins",
+		"qr.save_failed":        "Failed to save check-in list",
+		"qr.too_small":          "The window is too small to scan the QR code, please enlarge the terminal",
+		"qr.select_address":     "Select the network address for the check-in QR code (phones must join the same network)",
+		"qr.no_lan":             "No LAN address found, the QR code uses localhost and other devices cannot reach it; set host or base_url under checkin in config.yml",
 
 		// Data Source
 		"data.loading":         "Loading data...",
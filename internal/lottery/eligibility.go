@@ -0,0 +1,163 @@
+package lottery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// EligibilityRule 奖项的参与条件，例如：
+//
+//	"id != 1,2,3"        排除指定 ID
+//	"department != 总裁办" 排除指定部门
+//	"department = 技术部"  只允许指定部门
+//	"tenure >= 1"        司龄满一年
+//	"attr.职级 != M1"     按名单中的其他列筛选
+//
+// 一个奖项的多条规则必须同时满足
+type EligibilityRule struct {
+	Field  string
+	Op     string   // =, !=, >, >=, <, <=
+	Values []string // = 和 != 可以列出多个取值，比较运算只有一个取值
+}
+
+func (r EligibilityRule) String() string {
+	return fmt.Sprintf("%s %s %s", r.Field, r.Op, strings.Join(r.Values, ","))
+}
+
+var eligibilityPattern = regexp.MustCompile(`^\s*(` + fieldPattern + `)\s*(!=|>=|<=|=|>|<)\s*(.+?)\s*$`)
+
+// numericFields 可以进行大小比较的字段
+var numericFields = map[string]bool{"id": true, "tenure": true, "attendance": true}
+
+// ParseEligibilityRule 解析形如 "tenure >= 1" 的参与条件
+func ParseEligibilityRule(s string) (EligibilityRule, error) {
+	m := eligibilityPattern.FindStringSubmatch(s)
+	if m == nil {
+		return EligibilityRule{}, fmt.Errorf("无效的参与条件 %q，格式应为 \"字段 运算符 取值\"", s)
+	}
+	rule := EligibilityRule{Field: strings.ToLower(m[1]), Op: m[2]}
+	if _, ok := participantAttribute(model.Participant{}, rule.Field); !ok {
+		return EligibilityRule{}, fmt.Errorf("参与条件 %q 引用了不支持的字段 %s", s, m[1])
+	}
+
+	for _, v := range strings.FieldsFunc(m[3], func(r rune) bool { return r == ',' || r == '，' }) {
+		if v = strings.TrimSpace(v); v != "" {
+			rule.Values = append(rule.Values, v)
+		}
+	}
+	if len(rule.Values) == 0 {
+		return EligibilityRule{}, fmt.Errorf("参与条件 %q 缺少取值", s)
+	}
+
+	if rule.Op != "=" && rule.Op != "!=" {
+		if !numericFields[rule.Field] {
+			return EligibilityRule{}, fmt.Errorf("参与条件 %q: 字段 %s 不支持 %s 比较", s, rule.Field, rule.Op)
+		}
+		if len(rule.Values) != 1 {
+			return EligibilityRule{}, fmt.Errorf("参与条件 %q: %s 只能有一个取值", s, rule.Op)
+		}
+	}
+	if numericFields[rule.Field] {
+		for _, v := range rule.Values {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return EligibilityRule{}, fmt.Errorf("参与条件 %q: %s 不是数字", s, v)
+			}
+		}
+	}
+	return rule, nil
+}
+
+// ParseEligibilityRules 解析奖项上配置的全部参与条件
+func ParseEligibilityRules(rules []string) ([]EligibilityRule, error) {
+	parsed := make([]EligibilityRule, 0, len(rules))
+	for _, rule := range rules {
+		r, err := ParseEligibilityRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// Match 判断参与者是否满足该条件
+func (r EligibilityRule) Match(p model.Participant) bool {
+	value, _ := participantAttribute(p, r.Field)
+
+	if numericFields[r.Field] {
+		x, _ := strconv.ParseFloat(value, 64)
+		switch r.Op {
+		case "=", "!=":
+			found := false
+			for _, v := range r.Values {
+				if y, _ := strconv.ParseFloat(v, 64); x == y {
+					found = true
+					break
+				}
+			}
+			return found == (r.Op == "=")
+		}
+		y, _ := strconv.ParseFloat(r.Values[0], 64) // 解析时已保证是数字
+		switch r.Op {
+		case ">":
+			return x > y
+		case ">=":
+			return x >= y
+		case "<":
+			return x < y
+		case "<=":
+			return x <= y
+		}
+		return false
+	}
+
+	found := false
+	for _, v := range r.Values {
+		if strings.EqualFold(value, v) {
+			found = true
+			break
+		}
+	}
+	return found == (r.Op == "=")
+}
+
+// matchAll 判断参与者是否满足全部条件
+func matchAll(rules []EligibilityRule, p model.Participant) bool {
+	for _, r := range rules {
+		if !r.Match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// participantAttribute 返回参与者在条件字段上的取值
+func participantAttribute(p model.Participant, field string) (string, bool) {
+	switch field {
+	case "id":
+		return strconv.Itoa(p.ID), true
+	case "name":
+		return p.Name, true
+	case "tenure":
+		return strconv.FormatFloat(p.Tenure, 'g', -1, 64), true
+	case "attendance":
+		return strconv.Itoa(p.Attendance), true
+	default:
+		return participantField(p, field)
+	}
+}
+
+// ValidatePrize 检查奖项的参与条件和配额规则，便于在抽奖开始前发现配置错误
+func ValidatePrize(prize model.Prize) error {
+	if _, err := ParseEligibilityRules(prize.Eligibility); err != nil {
+		return fmt.Errorf("[%s] %w", prize.Name, err)
+	}
+	if _, err := ParseQuotas(prize.Quotas); err != nil {
+		return fmt.Errorf("[%s] %w", prize.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package lottery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+func TestParseEligibilityRule(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     string
+		expected EligibilityRule
+		wantErr  bool
+	}{
+		{name: "排除多个 ID", rule: "id != 1, 2,3", expected: EligibilityRule{Field: "id", Op: "!=", Values: []string{"1", "2", "3"}}},
+		{name: "全角逗号分隔部门", rule: "Department = 技术部，市场部", expected: EligibilityRule{Field: "department", Op: "=", Values: []string{"技术部", "市场部"}}},
+		{name: "司龄下限", rule: "tenure>=1", expected: EligibilityRule{Field: "tenure", Op: ">=", Values: []string{"1"}}},
+		{name: "扩展属性", rule: "attr.职级 != M1,M2", expected: EligibilityRule{Field: "attr.职级", Op: "!=", Values: []string{"M1", "M2"}}},
+		{name: "不支持的字段", rule: "office != 北京", wantErr: true},
+		{name: "扩展属性缺少名称", rule: "attr. = 北京", wantErr: true},
+		{name: "扩展属性不支持大小比较", rule: "attr.level >= 3", wantErr: true},
+		{name: "文本字段不支持大小比较", rule: "department >= 技术部", wantErr: true},
+		{name: "比较运算只能有一个取值", rule: "tenure >= 1,2", wantErr: true},
+		{name: "数字字段的取值不是数字", rule: "attendance >= 两次", wantErr: true},
+		{name: "缺少取值", rule: "id != ,", wantErr: true},
+		{name: "缺少运算符", rule: "tenure 1", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseEligibilityRule(tc.rule)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, r)
+		})
+	}
+}
+
+func TestEligibilityRule_Match(t *testing.T) {
+	p := model.Participant{ID: 7, Name: "张三", Department: "技术部", Tenure: 1.5, Attendance: 2, Attributes: map[string]string{"Site": "上海"}}
+
+	testCases := []struct {
+		rule     string
+		expected bool
+	}{
+		{rule: "id != 1,2", expected: true},
+		{rule: "id != 7", expected: false},
+		{rule: "id = 7,8", expected: true},
+		{rule: "department != 总裁办,组委会", expected: true},
+		{rule: "department != 技术部", expected: false},
+		{rule: "department = 市场部", expected: false},
+		{rule: "tenure >= 1", expected: true},
+		{rule: "tenure > 1.5", expected: false},
+		{rule: "attendance < 3", expected: true},
+		{rule: "attendance <= 1", expected: false},
+		{rule: "name = 张三", expected: true},
+		{rule: "attr.site = 上海", expected: true},
+		{rule: "attr.Site != 上海", expected: false},
+		{rule: "attr.职级 = M1", expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			r, err := ParseEligibilityRule(tc.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, r.Match(p))
+		})
+	}
+}
+
+func TestEngine_DrawWithEligibility(t *testing.T) {
+	participants := []model.Participant{
+		{ID: 1, Name: "董事长", Department: "总裁办", Tenure: 10},
+		{ID: 2, Name: "组委", Department: "组委会", Tenure: 3},
+		{ID: 3, Name: "新人", Department: "技术部", Tenure: 0.5},
+		{ID: 4, Name: "老员工甲", Department: "技术部", Tenure: 2},
+		{ID: 5, Name: "老员工乙", Department: "市场部", Tenure: 4},
+		{ID: 6, Name: "老员工丙", Department: "财务部", Tenure: 1},
+	}
+	rules := []string{"department != 总裁办,组委会", "tenure >= 1"}
+	prizes := []model.Prize{
+		{ID: 1, Name: "特等奖", Level: model.PrizeLevelSpecial, Count: 5, Probability: 0.1, Eligibility: rules},
+		{ID: 2, Name: "阳光普照", Level: model.PrizeLevel3, Count: 3, Probability: 0.9},
+	}
+	engine := NewEngine(participants, prizes, 1)
+
+	count, err := engine.EligibleCount(1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count, "只有司龄满一年且不在排除部门的人符合条件")
+
+	// 名额多于符合条件的人数时，只有符合条件的人中奖
+	winners, err := engine.Draw(1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{4, 5, 6}, participantIDs(winners))
+
+	// 没有条件的奖项对剩余所有人开放
+	count, err = engine.EligibleCount(2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	_, err = engine.EligibleCount(99)
+	assert.ErrorIs(t, err, ErrPrizeNotFound)
+}
+
+func TestEngine_DrawWithInvalidEligibility(t *testing.T) {
+	prizes := []model.Prize{{ID: 1, Name: "特等奖", Count: 1, Probability: 0.1, Eligibility: []string{"office != 北京"}}}
+	engine := NewEngine(createTestParticipants(5), prizes, 1)
+
+	_, err := engine.Draw(1)
+	require.Error(t, err)
+	_, err = engine.EligibleCount(1)
+	require.Error(t, err)
+	assert.Error(t, ValidatePrize(prizes[0]))
+	assert.Empty(t, engine.Events(), "条件有误时不应记录抽奖")
+}
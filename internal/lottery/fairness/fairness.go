@@ -0,0 +1,102 @@
+// Package fairness 实现抽奖的承诺-揭示（commit-reveal）协议。
+//
+// 抽奖开始前公布承诺：SHA-256(盐 | 种子 | 名单摘要 | 奖品摘要)；
+// 抽奖结束后公开种子和盐，任何人都可以重新计算承诺，
+// 并用由种子派生的随机数离线重放每一次抽奖，核对中奖结果。
+package fairness
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// 各类哈希的域分隔前缀，避免不同用途的哈希互相碰撞
+const (
+	commitDomain = "lucky-day/commit/v1"
+	drawDomain   = "lucky-day/draw/v1"
+	rosterDomain = "lucky-day/roster/v1"
+	prizesDomain = "lucky-day/prizes/v1"
+	configDomain = "lucky-day/config/v1"
+)
+
+// saltSize 盐的字节数，保证承诺在揭示前无法被暴力反推出种子
+const saltSize = 32
+
+// NewSalt 生成一个十六进制编码的随机盐
+func NewSalt() (string, error) {
+	b := make([]byte, saltSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机盐失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RosterDigest 计算参与者名单的摘要，与名单顺序无关
+func RosterDigest(participants []model.Participant) string {
+	sorted := slices.Clone(participants)
+	slices.SortFunc(sorted, func(a, b model.Participant) int {
+		return a.ID - b.ID
+	})
+	return digest(rosterDomain, sorted)
+}
+
+// PrizesDigest 计算奖品配置的摘要，已抽取数量和每批人数不参与计算。
+// 每批人数可以在抽奖现场调整，实际抽取的人数记录在每条抽奖操作中。
+func PrizesDigest(prizes []model.Prize) string {
+	initial := slices.Clone(prizes)
+	for i := range initial {
+		initial[i].DrawnCount = 0
+		initial[i].BatchSize = 0
+	}
+	return digest(prizesDomain, initial)
+}
+
+// ConfigDigest 计算影响抽奖结果的其他配置（如权重策略）的摘要
+func ConfigDigest(description string) string {
+	return digest(configDomain, description)
+}
+
+// Commit 计算抽奖前公布的承诺值，digests 依次为名单、奖品及其他配置的摘要
+func Commit(seed int64, salt string, digests ...string) string {
+	h := sha256.New()
+	h.Write([]byte(commitDomain))
+	h.Write([]byte(salt))
+	_ = binary.Write(h, binary.BigEndian, seed) // 写入 hash 不会失败
+	for _, d := range digests {
+		h.Write([]byte(d))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DrawSeed 由种子派生某一次抽奖使用的随机种子。
+// seq 是该次抽奖在操作序列中的序号，prizeID 是奖项 ID，
+// 每次抽奖的随机性互相独立，且只由公开后的种子决定。
+func DrawSeed(seed int64, seq, prizeID int) int64 {
+	h := sha256.New()
+	h.Write([]byte(drawDomain))
+	_ = binary.Write(h, binary.BigEndian, seed)
+	_ = binary.Write(h, binary.BigEndian, int64(seq))
+	_ = binary.Write(h, binary.BigEndian, int64(prizeID))
+	sum := h.Sum(nil)
+	return int64(binary.BigEndian.Uint64(sum[:8]) &^ (1 << 63))
+}
+
+// digest 对数据的规范 JSON 编码计算带域前缀的 SHA-256
+func digest(domain string, v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// model 中的结构体都可以被编码，这里出错说明模型定义有误
+		panic(fmt.Sprintf("fairness: 无法编码摘要数据: %v", err))
+	}
+	h := sha256.New()
+	h.Write([]byte(domain))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
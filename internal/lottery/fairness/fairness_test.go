@@ -0,0 +1,94 @@
+package fairness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+func testRoster() []model.Participant {
+	return []model.Participant{
+		{ID: 1, Name: "张三"},
+		{ID: 2, Name: "李四", WinningHistory: []model.WinningRecord{{Year: 2024, PrizeLevel: 3}}},
+		{ID: 3, Name: "王五"},
+	}
+}
+
+func testPrizes() []model.Prize {
+	return []model.Prize{
+		{ID: 1, Name: "一等奖", Level: model.PrizeLevel1, Count: 1, Probability: 0.1},
+		{ID: 2, Name: "二等奖", Level: model.PrizeLevel2, Count: 2, Probability: 0.5},
+	}
+}
+
+func TestNewSalt(t *testing.T) {
+	a, err := NewSalt()
+	require.NoError(t, err)
+	b, err := NewSalt()
+	require.NoError(t, err)
+
+	assert.Len(t, a, saltSize*2, "盐应为十六进制编码")
+	assert.NotEqual(t, a, b, "每次生成的盐应不同")
+}
+
+func TestRosterDigest(t *testing.T) {
+	roster := testRoster()
+	reversed := []model.Participant{roster[2], roster[1], roster[0]}
+
+	assert.Equal(t, RosterDigest(roster), RosterDigest(reversed), "摘要应与名单顺序无关")
+
+	renamed := testRoster()
+	renamed[0].Name = "张三丰"
+	assert.NotEqual(t, RosterDigest(roster), RosterDigest(renamed), "修改姓名应改变摘要")
+
+	history := testRoster()
+	history[1].WinningHistory = nil
+	assert.NotEqual(t, RosterDigest(roster), RosterDigest(history), "修改中奖历史应改变摘要")
+}
+
+func TestPrizesDigest(t *testing.T) {
+	prizes := testPrizes()
+
+	drawn := testPrizes()
+	drawn[0].DrawnCount = 1
+	assert.Equal(t, PrizesDigest(prizes), PrizesDigest(drawn), "已抽取数量不应影响摘要")
+
+	changed := testPrizes()
+	changed[1].Count = 3
+	assert.NotEqual(t, PrizesDigest(prizes), PrizesDigest(changed), "修改奖品数量应改变摘要")
+}
+
+func TestCommit(t *testing.T) {
+	roster := RosterDigest(testRoster())
+	prizes := PrizesDigest(testPrizes())
+	base := Commit(42, "salt", roster, prizes)
+
+	assert.Equal(t, base, Commit(42, "salt", roster, prizes), "相同输入的承诺应一致")
+
+	testCases := []struct {
+		name   string
+		commit string
+	}{
+		{name: "篡改种子", commit: Commit(43, "salt", roster, prizes)},
+		{name: "篡改盐", commit: Commit(42, "salt2", roster, prizes)},
+		{name: "篡改名单", commit: Commit(42, "salt", RosterDigest(testRoster()[:2]), prizes)},
+		{name: "篡改奖品", commit: Commit(42, "salt", roster, PrizesDigest(testPrizes()[:1]))},
+		{name: "追加配置", commit: Commit(42, "salt", roster, prizes, ConfigDigest("uniform"))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NotEqual(t, base, tc.commit)
+		})
+	}
+}
+
+func TestDrawSeed(t *testing.T) {
+	assert.Equal(t, DrawSeed(42, 0, 1), DrawSeed(42, 0, 1), "派生种子应是确定的")
+	assert.NotEqual(t, DrawSeed(42, 0, 1), DrawSeed(42, 0, 2), "不同奖项的派生种子应不同")
+	assert.NotEqual(t, DrawSeed(42, 0, 1), DrawSeed(42, 1, 1), "不同序号的派生种子应不同")
+	assert.NotEqual(t, DrawSeed(42, 0, 1), DrawSeed(43, 0, 1), "不同种子的派生种子应不同")
+	assert.GreaterOrEqual(t, DrawSeed(42, 0, 1), int64(0))
+}
@@ -0,0 +1,165 @@
+package lottery
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// 中奖状态，用于导出中奖名单
+const (
+	StatusWon       = "Won"
+	StatusForfeited = "Forfeited"
+)
+
+// ErrNotWinner 弃奖的参与者不是该奖项的中奖者
+var ErrNotWinner = errors.New("该参与者不是此奖项的中奖者")
+
+// ForfeitRecord 一次弃奖记录
+type ForfeitRecord struct {
+	PrizeID     int
+	Participant model.Participant // 弃奖者
+	Replacement model.Participant // 补抽的中奖者
+	Excluded    bool              // 是否取消弃奖者后续所有奖项的抽奖资格
+}
+
+// Forfeit 将某个奖项的一名中奖者标记为弃奖，并补抽一名中奖者替换其位置。
+// excludeFuture 为 true 时弃奖者不再参与后续奖项，否则放回候选池（但不会再被补抽到本奖项）。
+// 没有可补抽的人时返回错误，引擎状态保持不变。
+func (e *Engine) Forfeit(prizeID, participantID int, excludeFuture bool) (model.Participant, error) {
+	prizeIndex := e.prizeIndex(prizeID)
+	if prizeIndex < 0 {
+		return model.Participant{}, ErrPrizeNotFound
+	}
+	if !slices.ContainsFunc(e.allWinners[prizeID], func(p model.Participant) bool { return p.ID == participantID }) {
+		return model.Participant{}, ErrNotWinner
+	}
+
+	seq := len(e.events)
+	replacement, err := e.pickReplacement(e.prizes[prizeIndex], participantID, e.drawRand(seq, prizeID))
+	if err != nil {
+		return model.Participant{}, err
+	}
+
+	ev := Event{
+		Op:            OpForfeit,
+		PrizeID:       prizeID,
+		ParticipantID: participantID,
+		Exclude:       excludeFuture,
+		WinnerIDs:     []int{replacement.ID},
+	}
+	e.do(ev,
+		func() { e.applyForfeit(prizeID, participantID, replacement, excludeFuture) },
+		func() { e.undoForfeit(prizeID) })
+	return replacement, nil
+}
+
+// pickReplacement 为弃奖者补抽一名中奖者：遵守奖项的参与条件和配额，
+// 且不会抽到本奖项已经弃奖的人
+func (e *Engine) pickReplacement(prize model.Prize, forfeitedID int, rng *rand.Rand) (model.Participant, error) {
+	quotas, err := ParseQuotas(prize.Quotas)
+	if err != nil {
+		return model.Participant{}, err
+	}
+	choices, err := e.getWeightedChoices(prize)
+	if err != nil {
+		return model.Participant{}, err
+	}
+
+	var forfeited model.Participant
+	remaining := make([]model.Participant, 0, len(e.allWinners[prize.ID]))
+	for _, w := range e.allWinners[prize.ID] {
+		if w.ID == forfeitedID {
+			forfeited = w
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	allowed := replacementAllowed(quotas, remaining, forfeited)
+
+	choices = slices.DeleteFunc(choices, func(c weightedChoice) bool {
+		return e.forfeitedFrom(prize.ID, c.Participant.ID) || !allowed(c.Participant)
+	})
+	if len(choices) == 0 {
+		if len(quotas) > 0 {
+			return model.Participant{}, &QuotaError{PrizeName: prize.Name, Reason: fmt.Sprintf("没有符合配额的候选人可以替换 %s", forfeited.Name)}
+		}
+		return model.Participant{}, ErrNoCandidates
+	}
+
+	return pickOne(choices, rng), nil
+}
+
+// applyForfeit 用补抽的中奖者替换弃奖者，并记录弃奖
+func (e *Engine) applyForfeit(prizeID, participantID int, replacement model.Participant, excludeFuture bool) {
+	winners := e.allWinners[prizeID]
+	i := slices.IndexFunc(winners, func(p model.Participant) bool { return p.ID == participantID })
+	forfeited := winners[i]
+	winners[i] = replacement // 补抽者占据弃奖者的位置
+
+	e.removeEligible(replacement)
+	if !excludeFuture {
+		e.addEligible(forfeited)
+	}
+	e.forfeits = append(e.forfeits, ForfeitRecord{
+		PrizeID:     prizeID,
+		Participant: forfeited,
+		Replacement: replacement,
+		Excluded:    excludeFuture,
+	})
+}
+
+// undoForfeit 撤销最近一次弃奖：弃奖者回到补抽者的位置，补抽者放回候选池
+func (e *Engine) undoForfeit(prizeID int) {
+	f := e.forfeits[len(e.forfeits)-1]
+	e.forfeits = e.forfeits[:len(e.forfeits)-1]
+
+	winners := e.allWinners[prizeID]
+	i := slices.IndexFunc(winners, func(p model.Participant) bool { return p.ID == f.Replacement.ID })
+	winners[i] = f.Participant
+	if !f.Excluded {
+		e.removeEligible(f.Participant)
+	}
+	e.addEligible(f.Replacement)
+}
+
+// forfeitedFrom 判断参与者是否已经放弃过该奖项
+func (e *Engine) forfeitedFrom(prizeID, participantID int) bool {
+	return slices.ContainsFunc(e.forfeits, func(f ForfeitRecord) bool {
+		return f.PrizeID == prizeID && f.Participant.ID == participantID
+	})
+}
+
+// GetForfeits 返回所有弃奖记录
+func (e *Engine) GetForfeits() []ForfeitRecord {
+	return slices.Clone(e.forfeits)
+}
+
+// replacementAllowed 返回补抽时的配额过滤条件：
+// 有上限的分组已满时不能再补入，弃奖使某分组低于下限时只能从该分组补抽
+func replacementAllowed(quotas []Quota, remaining []model.Participant, forfeited model.Participant) func(model.Participant) bool {
+	taken := func(q Quota, v string) int {
+		n := 0
+		for _, w := range remaining {
+			if wv, _ := participantField(w, q.Field); wv == v {
+				n++
+			}
+		}
+		return n
+	}
+	return func(p model.Participant) bool {
+		for _, q := range quotas {
+			v, _ := participantField(p, q.Field)
+			if q.Max && taken(q, v) >= q.Limit {
+				return false
+			}
+			if fv, _ := participantField(forfeited, q.Field); !q.Max && v != fv && taken(q, fv) < q.Limit {
+				return false
+			}
+		}
+		return true
+	}
+}
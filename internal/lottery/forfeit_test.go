@@ -0,0 +1,177 @@
+package lottery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+func TestEngine_Forfeit(t *testing.T) {
+	testCases := []struct {
+		name          string
+		excludeFuture bool
+	}{
+		{name: "弃奖者放回候选池", excludeFuture: false},
+		{name: "弃奖者取消后续资格", excludeFuture: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := NewEngine(createTestParticipants(20), createTestPrizes(), 1)
+			winners, err := engine.Draw(2)
+			require.NoError(t, err)
+			absent := winners[1]
+
+			replacement, err := engine.Forfeit(2, absent.ID, tc.excludeFuture)
+			require.NoError(t, err)
+			assert.NotEqual(t, absent.ID, replacement.ID)
+			assert.NotContains(t, participantIDs(winners), replacement.ID, "补抽者不应是已中奖的人")
+
+			// 补抽者占据弃奖者的位置，名额不变
+			current := engine.GetAllWinners()[2]
+			assert.Equal(t, []int{winners[0].ID, replacement.ID, winners[2].ID}, participantIDs(current))
+			assert.Equal(t, 3, engine.GetPrizes()[1].DrawnCount)
+
+			_, inPool := engine.eligible[absent.ID]
+			assert.Equal(t, !tc.excludeFuture, inPool)
+			_, inPool = engine.eligible[replacement.ID]
+			assert.False(t, inPool, "补抽者应移出候选池")
+
+			forfeits := engine.GetForfeits()
+			require.Len(t, forfeits, 1)
+			assert.Equal(t, ForfeitRecord{PrizeID: 2, Participant: absent, Replacement: replacement, Excluded: tc.excludeFuture}, forfeits[0])
+
+			events := engine.Events()
+			assert.Equal(t, Event{Seq: 1, Op: OpForfeit, PrizeID: 2, ParticipantID: absent.ID, Exclude: tc.excludeFuture, WinnerIDs: []int{replacement.ID}}, events[1])
+		})
+	}
+}
+
+func TestEngine_ForfeitNeverRedrawsForfeiter(t *testing.T) {
+	// 只有 3 人：中奖者弃奖后即使放回候选池，也不能再被补抽到同一奖项
+	engine := NewEngine(createTestParticipants(3), []model.Prize{{ID: 1, Name: "一等奖", Count: 1}}, 1)
+	winners, err := engine.Draw(1)
+	require.NoError(t, err)
+
+	first, err := engine.Forfeit(1, winners[0].ID, false)
+	require.NoError(t, err)
+	second, err := engine.Forfeit(1, first.ID, false)
+	require.NoError(t, err)
+	assert.NotContains(t, []int{winners[0].ID, first.ID}, second.ID)
+
+	_, err = engine.Forfeit(1, second.ID, false)
+	assert.ErrorIs(t, err, ErrNoCandidates, "所有人都弃奖后无人可补抽")
+	assert.Equal(t, []int{second.ID}, participantIDs(engine.GetAllWinners()[1]), "补抽失败时状态不变")
+}
+
+func TestEngine_ForfeitErrors(t *testing.T) {
+	engine := NewEngine(createTestParticipants(10), createTestPrizes(), 1)
+	winners, err := engine.Draw(1)
+	require.NoError(t, err)
+
+	_, err = engine.Forfeit(99, winners[0].ID, false)
+	assert.ErrorIs(t, err, ErrPrizeNotFound)
+
+	_, err = engine.Forfeit(1, winners[0].ID+1, false)
+	assert.ErrorIs(t, err, ErrNotWinner)
+
+	_, err = engine.Forfeit(2, winners[0].ID, false)
+	assert.ErrorIs(t, err, ErrNotWinner, "尚未抽取的奖项没有中奖者")
+	assert.Len(t, engine.Events(), 1, "失败的弃奖不应记录")
+}
+
+func TestEngine_ForfeitRespectsQuotas(t *testing.T) {
+	participants := createDepartmentParticipants(map[string]int{"技术部": 10, "市场部": 10, "财务部": 1}, "技术部", "市场部", "财务部")
+
+	t.Run("上限", func(t *testing.T) {
+		prizes := []model.Prize{{ID: 1, Name: "购物卡", Count: 4, Probability: 1, Quotas: []string{"department <= 2"}}}
+		for seed := range int64(10) {
+			engine := NewEngine(participants, prizes, seed)
+			winners, err := engine.Draw(1)
+			require.NoError(t, err)
+
+			_, err = engine.Forfeit(1, winners[0].ID, true)
+			require.NoError(t, err)
+			for dept, n := range countByDepartment(engine.GetAllWinners()[1]) {
+				assert.LessOrEqual(t, n, 2, "%s 中奖人数超出上限", dept)
+			}
+		}
+	})
+
+	t.Run("下限", func(t *testing.T) {
+		prizes := []model.Prize{{ID: 1, Name: "购物卡", Count: 3, Probability: 1, Quotas: []string{"department >= 1"}}}
+		engine := NewEngine(participants, prizes, 1)
+		winners, err := engine.Draw(1)
+		require.NoError(t, err)
+
+		// 财务部只有 1 人，弃奖后无法再满足每个部门至少 1 人
+		i := 0
+		for winners[i].Department != "财务部" {
+			i++
+		}
+		_, err = engine.Forfeit(1, winners[i].ID, false)
+		var quotaErr *QuotaError
+		require.ErrorAs(t, err, &quotaErr)
+
+		// 技术部弃奖只能由技术部补上
+		for winners[i].Department != "技术部" {
+			i = (i + 1) % len(winners)
+		}
+		replacement, err := engine.Forfeit(1, winners[i].ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, "技术部", replacement.Department)
+	})
+}
+
+func TestEngine_ResetPrizeAfterForfeit(t *testing.T) {
+	engine := NewEngine(createTestParticipants(10), createTestPrizes(), 1)
+	winners, err := engine.Draw(2)
+	require.NoError(t, err)
+	_, err = engine.Forfeit(2, winners[0].ID, true)
+	require.NoError(t, err)
+
+	engine.ResetPrize(2)
+	assert.Empty(t, engine.GetForfeits(), "重置奖项应清除该奖项的弃奖记录")
+	assert.Len(t, engine.GetEligibleParticipants(), 10, "被取消资格的弃奖者也应放回候选池")
+}
+
+func TestVerifyWithForfeits(t *testing.T) {
+	participants := createTestParticipants(30)
+	prizes := createTestPrizes()
+	engine := NewEngine(participants, prizes, 20250101)
+	commitment, err := engine.Commit()
+	require.NoError(t, err)
+
+	winners, err := engine.Draw(2)
+	require.NoError(t, err)
+	_, err = engine.Forfeit(2, winners[0].ID, true)
+	require.NoError(t, err)
+	_, err = engine.Forfeit(2, winners[2].ID, false)
+	require.NoError(t, err)
+	_, err = engine.Draw(3)
+	require.NoError(t, err)
+	reveal := engine.Reveal()
+
+	report := Verify(participants, prizes, nil, reveal, commitment)
+	assert.True(t, report.Passed(), "%+v", report)
+
+	t.Run("篡改补抽结果", func(t *testing.T) {
+		tampered := reveal
+		tampered.Events = engineEventsCopy(reveal.Events)
+		tampered.Events[1].WinnerIDs = []int{findLoser(reveal.Events, len(participants))}
+		report := Verify(participants, prizes, nil, tampered, commitment)
+		assert.False(t, report.Passed())
+		assert.False(t, report.Prizes[1].Passed)
+	})
+
+	t.Run("篡改弃奖者", func(t *testing.T) {
+		tampered := reveal
+		tampered.Events = engineEventsCopy(reveal.Events)
+		tampered.Events[1].ParticipantID = findLoser(reveal.Events, len(participants))
+		report := Verify(participants, prizes, nil, tampered, commitment)
+		assert.False(t, report.Passed())
+	})
+}
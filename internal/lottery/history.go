@@ -0,0 +1,64 @@
+package lottery
+
+import "errors"
+
+// 撤销、重做失败的原因
+var (
+	ErrNothingToUndo = errors.New("没有可撤销的操作")
+	ErrNothingToRedo = errors.New("没有可重做的操作")
+)
+
+// operation 一次可撤销的操作。只保存操作本身的变化量，撤销时执行逆操作，
+// 因此撤销栈占用的内存与操作涉及的人数成正比，与名单大小无关
+type operation struct {
+	event Event
+	apply func() // 执行或重做操作
+	undo  func() // 撤销操作，恢复到执行前的状态
+}
+
+// do 执行一次会修改状态的操作并记录，使其可以被撤销；新操作会清空重做栈
+func (e *Engine) do(ev Event, apply, undo func()) {
+	apply()
+	e.record(ev)
+	e.undoStack = append(e.undoStack, operation{event: e.events[len(e.events)-1], apply: apply, undo: undo})
+	e.redoStack = nil
+}
+
+// Undo 撤销最近一次抽奖、重置或弃奖，返回被撤销的操作记录。
+// 撤销本身也会记录到操作日志中，复核时按相同顺序重放。
+func (e *Engine) Undo() (Event, error) {
+	if len(e.undoStack) == 0 {
+		return Event{}, ErrNothingToUndo
+	}
+	op := e.undoStack[len(e.undoStack)-1]
+	e.undoStack = e.undoStack[:len(e.undoStack)-1]
+
+	op.undo()
+	e.redoStack = append(e.redoStack, op)
+	e.record(Event{Op: OpUndo, PrizeID: op.event.PrizeID})
+	return op.event, nil
+}
+
+// Redo 重做最近一次被撤销的操作，结果与撤销前完全相同，不会重新抽取
+func (e *Engine) Redo() (Event, error) {
+	if len(e.redoStack) == 0 {
+		return Event{}, ErrNothingToRedo
+	}
+	op := e.redoStack[len(e.redoStack)-1]
+	e.redoStack = e.redoStack[:len(e.redoStack)-1]
+
+	op.apply()
+	e.undoStack = append(e.undoStack, op)
+	e.record(Event{Op: OpRedo, PrizeID: op.event.PrizeID})
+	return op.event, nil
+}
+
+// CanUndo 是否有可撤销的操作
+func (e *Engine) CanUndo() bool {
+	return len(e.undoStack) > 0
+}
+
+// CanRedo 是否有可重做的操作
+func (e *Engine) CanRedo() bool {
+	return len(e.redoStack) > 0
+}
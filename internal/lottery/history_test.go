@@ -0,0 +1,178 @@
+package lottery
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// engineState 引擎可变状态的快照，用于比较撤销、重做和恢复前后的状态
+type engineState struct {
+	eligible    map[int]model.Participant
+	eligibleIDs []int
+	allWinners  map[int][]model.Participant
+	prizes      []model.Prize
+	forfeits    []ForfeitRecord
+}
+
+// snapshot 复制当前状态，之后对引擎的修改不会影响快照；空的弃奖记录统一为 nil 便于比较
+func (e *Engine) snapshot() engineState {
+	winners := make(map[int][]model.Participant, len(e.allWinners))
+	for id, w := range e.allWinners {
+		winners[id] = slices.Clone(w)
+	}
+	return engineState{
+		eligible:    maps.Clone(e.eligible),
+		eligibleIDs: slices.Clone(e.eligibleIDs),
+		allWinners:  winners,
+		prizes:      slices.Clone(e.prizes),
+		forfeits:    append([]ForfeitRecord(nil), e.forfeits...),
+	}
+}
+
+func TestEngine_UndoRedo(t *testing.T) {
+	engine := NewEngine(createTestParticipants(30), createTestPrizes(), 1)
+	initial := engine.snapshot()
+
+	_, err := engine.Undo()
+	require.ErrorIs(t, err, ErrNothingToUndo)
+	_, err = engine.Redo()
+	require.ErrorIs(t, err, ErrNothingToRedo)
+
+	// 依次执行抽奖、弃奖、重置，记录每一步之后的状态
+	first, err := engine.Draw(2)
+	require.NoError(t, err)
+	afterDraw := engine.snapshot()
+	_, err = engine.Draw(3)
+	require.NoError(t, err)
+	afterSecondDraw := engine.snapshot()
+	_, err = engine.Forfeit(2, first[0].ID, true)
+	require.NoError(t, err)
+	afterForfeit := engine.snapshot()
+	engine.ResetPrize(3)
+	afterReset := engine.snapshot()
+
+	// 逐步撤销，每一步都应精确回到之前的状态
+	steps := []struct {
+		op       string
+		expected engineState
+	}{
+		{op: OpReset, expected: afterForfeit},
+		{op: OpForfeit, expected: afterSecondDraw},
+		{op: OpDraw, expected: afterDraw},
+		{op: OpDraw, expected: initial},
+	}
+	for _, step := range steps {
+		ev, err := engine.Undo()
+		require.NoError(t, err)
+		assert.Equal(t, step.op, ev.Op)
+		assert.Equal(t, step.expected, engine.snapshot())
+	}
+	assert.False(t, engine.CanUndo())
+
+	// 逐步重做，结果与撤销前完全相同
+	for _, expected := range []engineState{afterDraw, afterSecondDraw, afterForfeit, afterReset} {
+		_, err := engine.Redo()
+		require.NoError(t, err)
+		assert.Equal(t, expected, engine.snapshot())
+	}
+	assert.False(t, engine.CanRedo())
+}
+
+func TestEngine_NewOperationClearsRedo(t *testing.T) {
+	engine := NewEngine(createTestParticipants(30), createTestPrizes(), 1)
+	_, err := engine.Draw(1)
+	require.NoError(t, err)
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	assert.True(t, engine.CanRedo())
+
+	_, err = engine.Draw(2)
+	require.NoError(t, err)
+	assert.False(t, engine.CanRedo(), "新操作后不能再重做已撤销的操作")
+	assert.Empty(t, engine.GetAllWinners()[1])
+}
+
+func TestEngine_UndoIsLogged(t *testing.T) {
+	engine := NewEngine(createTestParticipants(30), createTestPrizes(), 1)
+	_, err := engine.Draw(1)
+	require.NoError(t, err)
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	_, err = engine.Redo()
+	require.NoError(t, err)
+
+	ops := make([]string, 0, 3)
+	for _, ev := range engine.Events() {
+		ops = append(ops, ev.Op)
+	}
+	assert.Equal(t, []string{OpDraw, OpUndo, OpRedo}, ops, "撤销和重做应记录在操作日志中")
+}
+
+func TestVerifyWithUndoRedo(t *testing.T) {
+	participants := createTestParticipants(30)
+	prizes := createTestPrizes()
+	engine := NewEngine(participants, prizes, 20250101)
+	commitment, err := engine.Commit()
+	require.NoError(t, err)
+
+	_, err = engine.Draw(1)
+	require.NoError(t, err)
+	_, err = engine.Draw(2)
+	require.NoError(t, err)
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	_, err = engine.Redo()
+	require.NoError(t, err)
+	_, err = engine.Draw(2) // 撤销后重新抽取
+	require.NoError(t, err)
+	_, err = engine.Draw(3)
+	require.NoError(t, err)
+	reveal := engine.Reveal()
+
+	report := Verify(participants, prizes, nil, reveal, commitment)
+	assert.True(t, report.Passed(), "%+v", report)
+
+	t.Run("删除撤销记录", func(t *testing.T) {
+		tampered := reveal
+		tampered.Events = engineEventsCopy(reveal.Events[:2])
+		tampered.Events = append(tampered.Events, engineEventsCopy(reveal.Events[3:])...)
+		report := Verify(participants, prizes, nil, tampered, commitment)
+		assert.False(t, report.Passed())
+	})
+}
+
+func TestEngine_UndoResetRestoresForfeits(t *testing.T) {
+	engine := NewEngine(createTestParticipants(30), createTestPrizes(), 7)
+	second, err := engine.Draw(2)
+	require.NoError(t, err)
+	third, err := engine.Draw(3)
+	require.NoError(t, err)
+
+	// 两个奖项的弃奖记录交错排列，撤销重置后应回到原来的位置
+	_, err = engine.Forfeit(2, second[0].ID, false)
+	require.NoError(t, err)
+	_, err = engine.Forfeit(3, third[0].ID, true)
+	require.NoError(t, err)
+	_, err = engine.Forfeit(2, second[1].ID, true)
+	require.NoError(t, err)
+	beforeReset := engine.snapshot()
+
+	engine.ResetPrize(2)
+	afterReset := engine.snapshot()
+	require.Len(t, afterReset.forfeits, 1)
+
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	assert.Equal(t, beforeReset, engine.snapshot())
+	_, err = engine.Redo()
+	require.NoError(t, err)
+	assert.Equal(t, afterReset, engine.snapshot())
+}
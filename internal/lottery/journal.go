@@ -0,0 +1,285 @@
+package lottery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/palemoky/lucky-day/internal/lottery/fairness"
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// journalVersion 日志格式版本
+const journalVersion = 1
+
+// JournalHeader 日志的第一行，保存重建引擎所需的全部信息。
+// 名单和奖品完整保存在日志中，恢复时不依赖原始数据源（例如扫码签到名单）。
+type JournalHeader struct {
+	Version      int                 `json:"version"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Commitment   string              `json:"commitment"`
+	Seed         int64               `json:"seed"`
+	Salt         string              `json:"salt"`
+	Weighting    string              `json:"weighting"`
+	EventYear    int                 `json:"event_year"`
+	RosterDigest string              `json:"roster_digest"`
+	PrizesDigest string              `json:"prizes_digest"`
+	Participants []model.Participant `json:"participants"`
+	Prizes       []model.Prize       `json:"prizes"`
+	Meta         map[string]string   `json:"meta,omitempty"` // 调用方附加的信息，如数据来源
+}
+
+// journalLine 日志中的一行，除第一行外每行是一条操作记录
+type journalLine struct {
+	Header *JournalHeader `json:"header,omitempty"`
+	Event  *Event         `json:"event,omitempty"`
+}
+
+// journalFile 只追加的会话日志，每条操作写入后立即落盘，程序崩溃后可以据此恢复
+type journalFile struct {
+	f *os.File
+}
+
+// StartJournal 创建新的会话日志并写入日志头，之后引擎的每次操作都会追加到日志中。
+// 必须在 Commit 之后调用；path 已存在时返回错误，避免覆盖上一场的日志。
+func (e *Engine) StartJournal(path string, meta map[string]string) error {
+	if e.commitment == "" {
+		return errors.New("创建会话日志前必须先调用 Commit")
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("创建会话日志失败: %w", err)
+	}
+	j := &journalFile{f: f}
+
+	header := JournalHeader{
+		Version:      journalVersion,
+		CreatedAt:    e.Now(),
+		Commitment:   e.commitment,
+		Seed:         e.seed,
+		Salt:         e.salt,
+		Weighting:    e.weights.String(),
+		EventYear:    e.eventYear,
+		RosterDigest: fairness.RosterDigest(e.allParticipants),
+		PrizesDigest: fairness.PrizesDigest(e.prizes),
+		Participants: e.allParticipants,
+		Prizes:       e.initialPrizes(),
+		Meta:         meta,
+	}
+	if err := j.write(journalLine{Header: &header}); err != nil {
+		_ = j.close()
+		return err
+	}
+	for _, ev := range e.events {
+		if err := j.append(ev); err != nil {
+			_ = j.close()
+			return err
+		}
+	}
+	e.journal = j
+	return nil
+}
+
+// ResumeJournal 读取上一场的会话日志，重放其中的全部操作重建引擎，并继续向该日志追加。
+// weights 必须与日志记录的权重策略一致；重放结果与日志不符时返回错误。
+func ResumeJournal(path string, weights WeightStrategy) (*Engine, JournalHeader, error) {
+	e, header, validSize, err := replayJournal(path, weights)
+	if err != nil {
+		return nil, header, err
+	}
+
+	// 崩溃时可能留下写了一半的最后一行，截掉后继续追加
+	if err := os.Truncate(path, validSize); err != nil {
+		return nil, header, fmt.Errorf("修复会话日志失败: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, header, fmt.Errorf("打开会话日志失败: %w", err)
+	}
+	e.journal = &journalFile{f: f}
+	return e, header, nil
+}
+
+// JournalPending 判断会话日志记录的抽奖是否还有奖项没有抽完，用于启动时决定是否提示恢复。
+// 日志不存在时返回 false；所有奖项都已抽完的会话无需恢复。日志无法重放时返回错误。
+func JournalPending(path string, weights WeightStrategy) (bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	e, _, _, err := replayJournal(path, weights)
+	if err != nil {
+		return false, err
+	}
+	return !e.Finished(), nil
+}
+
+// replayJournal 读取会话日志并重放其中的全部操作重建引擎，不修改日志文件。
+// 同时返回完整记录所占的字节数
+func replayJournal(path string, weights WeightStrategy) (*Engine, JournalHeader, int64, error) {
+	header, events, validSize, err := readJournal(path)
+	if err != nil {
+		return nil, JournalHeader{}, 0, err
+	}
+	if weights == nil {
+		weights = DefaultWeightStrategy()
+	}
+	if weights.String() != header.Weighting {
+		return nil, header, 0, fmt.Errorf("权重策略与日志不一致: 日志为 %s，当前配置为 %s", header.Weighting, weights.String())
+	}
+	if fairness.RosterDigest(header.Participants) != header.RosterDigest ||
+		fairness.PrizesDigest(header.Prizes) != header.PrizesDigest {
+		return nil, header, 0, errors.New("日志中的名单或奖品与摘要不一致，日志可能已损坏")
+	}
+
+	e := NewEngine(header.Participants, header.Prizes, header.Seed)
+	e.SetWeightStrategy(weights)
+	e.salt = header.Salt
+	e.eventYear = header.EventYear
+	e.commitment = commitFor(e.seed, e.salt, e.allParticipants, e.prizes, e.weights, e.eventYear)
+	if e.commitment != header.Commitment {
+		return nil, header, 0, errors.New("日志中的承诺值无法复现，日志可能已损坏")
+	}
+
+	for _, ev := range events {
+		if err := e.replay(ev); err != nil {
+			return nil, header, 0, fmt.Errorf("重放第 %d 条操作失败: %w", ev.Seq, err)
+		}
+	}
+	return e, header, validSize, nil
+}
+
+// replay 通过引擎的公开操作重放一条记录，并核对结果与记录一致
+func (e *Engine) replay(ev Event) error {
+	if ev.Seq != len(e.events) {
+		return fmt.Errorf("操作序号不连续: 期望 %d，实际 %d", len(e.events), ev.Seq)
+	}
+	switch ev.Op {
+	case OpDraw:
+		// 每批人数可能在现场调整过，按记录的人数重放
+		winners, err := e.drawBatch(ev.PrizeID, ev.Count)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(participantIDs(winners), ev.WinnerIDs) {
+			return fmt.Errorf("中奖者与日志不一致: 重放结果 %v，日志 %v", participantIDs(winners), ev.WinnerIDs)
+		}
+	case OpReset:
+		e.ResetPrize(ev.PrizeID)
+	case OpForfeit:
+		replacement, err := e.Forfeit(ev.PrizeID, ev.ParticipantID, ev.Exclude)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal([]int{replacement.ID}, ev.WinnerIDs) {
+			return fmt.Errorf("补抽结果与日志不一致: 重放结果 %d，日志 %v", replacement.ID, ev.WinnerIDs)
+		}
+	case OpUndo:
+		if _, err := e.Undo(); err != nil {
+			return err
+		}
+	case OpRedo:
+		if _, err := e.Redo(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("未知的操作类型 %q", ev.Op)
+	}
+	return nil
+}
+
+// readJournal 读取日志头和全部操作记录，返回完整记录所占的字节数。
+// 只有最后一行允许不完整（写入时崩溃），其余损坏的行视为错误。
+func readJournal(path string) (JournalHeader, []Event, int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return JournalHeader{}, nil, 0, fmt.Errorf("读取会话日志失败: %w", err)
+	}
+
+	var (
+		header    *JournalHeader
+		events    []Event
+		validSize int64
+	)
+	for lineNo := 1; len(data) > 0; lineNo++ {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break // 没有换行符的最后一行是写了一半的记录
+		}
+		var line journalLine
+		if err := json.Unmarshal(data[:end], &line); err != nil {
+			return JournalHeader{}, nil, 0, fmt.Errorf("会话日志第 %d 行已损坏: %w", lineNo, err)
+		}
+		switch {
+		case lineNo == 1 && line.Header != nil:
+			header = line.Header
+		case lineNo > 1 && line.Event != nil:
+			events = append(events, *line.Event)
+		default:
+			return JournalHeader{}, nil, 0, fmt.Errorf("会话日志第 %d 行格式错误", lineNo)
+		}
+		validSize += int64(end + 1)
+		data = data[end+1:]
+	}
+
+	if header == nil {
+		return JournalHeader{}, nil, 0, errors.New("会话日志缺少日志头")
+	}
+	if header.Version != journalVersion {
+		return JournalHeader{}, nil, 0, fmt.Errorf("不支持的会话日志版本: %d", header.Version)
+	}
+	return *header, events, validSize, nil
+}
+
+// append 追加一条操作记录
+func (j *journalFile) append(ev Event) error {
+	return j.write(journalLine{Event: &ev})
+}
+
+// write 写入一行并立即落盘
+func (j *journalFile) write(line journalLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("编码会话日志失败: %w", err)
+	}
+	if _, err := j.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入会话日志失败: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("写入会话日志失败: %w", err)
+	}
+	return nil
+}
+
+// close 关闭日志文件
+func (j *journalFile) close() error {
+	return j.f.Close()
+}
+
+// CloseJournal 关闭会话日志，之后的操作不再写入
+func (e *Engine) CloseJournal() error {
+	if e.journal == nil {
+		return nil
+	}
+	err := e.journal.close()
+	e.journal = nil
+	return err
+}
+
+// JournalErr 返回写入会话日志时遇到的第一个错误。
+// 写入失败不会中断抽奖，但之后的操作在崩溃后将无法恢复，界面应提示操作员。
+func (e *Engine) JournalErr() error {
+	return e.journalErr
+}
+
+// initialPrizes 返回未抽奖前的奖品配置
+func (e *Engine) initialPrizes() []model.Prize {
+	prizes := slices.Clone(e.prizes)
+	for i := range prizes {
+		prizes[i].DrawnCount = 0
+	}
+	return prizes
+}
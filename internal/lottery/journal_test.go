@@ -0,0 +1,228 @@
+package lottery
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startJournaledEngine 创建已公布承诺并开始写会话日志的引擎
+func startJournaledEngine(t *testing.T, path string) *Engine {
+	t.Helper()
+	engine := NewEngine(createTestParticipants(30), createTestPrizes(), 20250101)
+	engine.SetEventYear(2025)
+	_, err := engine.Commit()
+	require.NoError(t, err)
+	require.NoError(t, engine.StartJournal(path, map[string]string{"mode": "excel"}))
+	t.Cleanup(func() { _ = engine.CloseJournal() })
+	return engine
+}
+
+func TestResumeJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	engine := startJournaledEngine(t, path)
+
+	winners, err := engine.Draw(2)
+	require.NoError(t, err)
+	_, err = engine.Draw(1)
+	require.NoError(t, err)
+	_, err = engine.Forfeit(2, winners[0].ID, true)
+	require.NoError(t, err)
+	engine.ResetPrize(1)
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	require.NoError(t, engine.JournalErr())
+
+	// 模拟崩溃：不关闭日志，直接从文件恢复
+	resumed, header, err := ResumeJournal(path, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resumed.CloseJournal() })
+
+	assert.Equal(t, "excel", header.Meta["mode"])
+	assert.Equal(t, 2025, resumed.EventYear(), "活动年份应从日志恢复")
+	assert.Equal(t, engine.Commitment(), resumed.Commitment())
+	assert.Equal(t, engine.Events(), resumed.Events())
+	assert.Equal(t, engine.snapshot(), resumed.snapshot(), "已抽取的奖项和移出候选池的参与者应完全恢复")
+	assert.True(t, resumed.CanRedo(), "撤销历史也应恢复")
+
+	// 恢复后继续抽奖，与未崩溃时的结果一致，并继续追加到同一个日志
+	require.NoError(t, engine.CloseJournal())
+	expected, err := engine.Draw(3)
+	require.NoError(t, err)
+	actual, err := resumed.Draw(3)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	require.NoError(t, resumed.CloseJournal())
+
+	again, _, err := ResumeJournal(path, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = again.CloseJournal() })
+	assert.Equal(t, resumed.Events(), again.Events())
+
+	// 恢复后的结果可以通过复核
+	report := Verify(createTestParticipants(30), createTestPrizes(), nil, again.Reveal(), engine.Commitment())
+	assert.True(t, report.Passed(), "%+v", report)
+}
+
+func TestJournalPending(t *testing.T) {
+	dir := t.TempDir()
+
+	pending, err := JournalPending(filepath.Join(dir, "missing.jsonl"), nil)
+	require.NoError(t, err)
+	assert.False(t, pending, "没有日志时无需恢复")
+
+	path := filepath.Join(dir, "journal.jsonl")
+	engine := startJournaledEngine(t, path)
+	_, err = engine.Draw(1)
+	require.NoError(t, err)
+	pending, err = JournalPending(path, nil)
+	require.NoError(t, err)
+	assert.True(t, pending, "还有奖项没抽完时应提示恢复")
+
+	for _, prize := range engine.GetPrizes() {
+		for drawn := prize.DrawnCount; drawn < prize.Count; {
+			winners, err := engine.Draw(prize.ID)
+			require.NoError(t, err)
+			drawn += len(winners)
+		}
+	}
+	require.True(t, engine.Finished())
+	pending, err = JournalPending(path, nil)
+	require.NoError(t, err)
+	assert.False(t, pending, "所有奖项都已抽完的会话无需恢复")
+
+	// 撤销最后一轮后又有名额可抽
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	pending, err = JournalPending(path, nil)
+	require.NoError(t, err)
+	assert.True(t, pending)
+
+	_, err = JournalPending(path, UniformWeight{})
+	assert.Error(t, err, "权重策略不一致时应返回错误")
+}
+
+func TestResumeJournal_BatchSizeChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	engine := startJournaledEngine(t, path)
+
+	// 现场调整的每批人数不在日志头中，重放时按每条记录的人数抽取
+	require.NoError(t, engine.SetBatchSize(3, 2))
+	_, err := engine.Draw(3)
+	require.NoError(t, err)
+	require.NoError(t, engine.SetBatchSize(3, 1))
+	_, err = engine.Draw(3)
+	require.NoError(t, err)
+
+	resumed, _, err := ResumeJournal(path, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resumed.CloseJournal() })
+	assert.Equal(t, engine.snapshot(), resumed.snapshot())
+
+	report := Verify(createTestParticipants(30), createTestPrizes(), nil, engine.Reveal(), engine.Commitment())
+	assert.True(t, report.Passed(), "%+v", report)
+}
+
+func TestResumeJournal_TruncatedLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	engine := startJournaledEngine(t, path)
+	_, err := engine.Draw(1)
+	require.NoError(t, err)
+
+	// 模拟写到一半时崩溃
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"event":{"seq":1,"op":"dr`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	resumed, _, err := ResumeJournal(path, nil)
+	require.NoError(t, err)
+	assert.Len(t, resumed.Events(), 1, "写了一半的记录应被忽略")
+
+	// 截掉残缺行后可以继续追加
+	_, err = resumed.Draw(2)
+	require.NoError(t, err)
+	require.NoError(t, resumed.CloseJournal())
+	again, _, err := ResumeJournal(path, nil)
+	require.NoError(t, err)
+	assert.Len(t, again.Events(), 2)
+	require.NoError(t, again.CloseJournal())
+}
+
+func TestResumeJournal_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("文件不存在", func(t *testing.T) {
+		_, _, err := ResumeJournal(filepath.Join(dir, "missing.jsonl"), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("权重策略不一致", func(t *testing.T) {
+		path := filepath.Join(dir, "weights.jsonl")
+		startJournaledEngine(t, path)
+		_, _, err := ResumeJournal(path, UniformWeight{})
+		assert.ErrorContains(t, err, "权重策略")
+	})
+
+	t.Run("中间的记录损坏", func(t *testing.T) {
+		path := filepath.Join(dir, "corrupt.jsonl")
+		engine := startJournaledEngine(t, path)
+		_, err := engine.Draw(1)
+		require.NoError(t, err)
+		_, err = engine.Draw(2)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := splitLines(data)
+		lines[1] = []byte("not json")
+		require.NoError(t, os.WriteFile(path, joinLines(lines), 0o600))
+
+		_, _, err = ResumeJournal(path, nil)
+		assert.ErrorContains(t, err, "第 2 行")
+	})
+
+	t.Run("篡改中奖者", func(t *testing.T) {
+		path := filepath.Join(dir, "tampered.jsonl")
+		engine := startJournaledEngine(t, path)
+		_, err := engine.Draw(1)
+		require.NoError(t, err)
+		require.NoError(t, engine.CloseJournal())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := splitLines(data)
+		lines[1] = []byte(`{"event":{"seq":0,"op":"draw","prize_id":1,"winner_ids":[999]}}`)
+		require.NoError(t, os.WriteFile(path, joinLines(lines), 0o600))
+
+		_, _, err = ResumeJournal(path, nil)
+		assert.ErrorContains(t, err, "不一致")
+	})
+
+	t.Run("不会覆盖已有日志", func(t *testing.T) {
+		path := filepath.Join(dir, "existing.jsonl")
+		startJournaledEngine(t, path)
+		engine := NewEngine(createTestParticipants(3), createTestPrizes(), 1)
+		_, err := engine.Commit()
+		require.NoError(t, err)
+		assert.Error(t, engine.StartJournal(path, nil))
+	})
+
+	t.Run("未公布承诺", func(t *testing.T) {
+		engine := NewEngine(createTestParticipants(3), createTestPrizes(), 1)
+		assert.Error(t, engine.StartJournal(filepath.Join(dir, "uncommitted.jsonl"), nil))
+	})
+}
+
+func splitLines(data []byte) [][]byte {
+	return bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+}
+
+func joinLines(lines [][]byte) []byte {
+	return append(bytes.Join(lines, []byte("\n")), '\n')
+}
@@ -1,12 +1,14 @@
 package lottery
 
 import (
-	"math"
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
 	"math/rand"
+	"slices"
 	"time"
 
-	"github.com/mroth/weightedrand"
-
+	"github.com/palemoky/lucky-day/internal/lottery/fairness"
 	"github.com/palemoky/lucky-day/internal/model"
 )
 
@@ -15,144 +17,447 @@ type Engine struct {
 	allParticipants []model.Participant
 	prizes          []model.Prize
 	eligible        map[int]model.Participant   // 仍有资格抽奖的参与者
+	eligibleIDs     []int                       // eligible 的 ID，升序，与 eligible 同步更新，避免每次排序
+	poolVersion     int                         // 候选池每次变化时加一，用于判断缓存的预览人数是否过期
+	eligibleCounts  map[int]eligibleCount       // 缓存的每个奖项的预览人数，Key 是 Prize.ID
 	allWinners      map[int][]model.Participant // 所有奖项的中奖者，Key 是 Prize.ID
+	batchSizes      map[int]int                 // 每个奖项每次抽取的人数，Key 是 Prize.ID，0 表示一次抽完
+	seed            int64                       // 随机种子，相同的参与者、奖品和种子会抽出相同的结果
+	clock           func() time.Time            // 当前时间，默认 time.Now
+	eventYear       int                         // 活动所属年份，用于计算往年中奖的衰减权重；0 表示使用时钟的年份
+	animRng         *rand.Rand                  // 动画使用的随机源，与抽奖隔离，动画帧数不会影响抽奖结果
+	weights         WeightStrategy              // 权重策略
+	events          []Event                     // 按发生顺序记录的所有操作，用于赛后复核
+	forfeits        []ForfeitRecord             // 弃奖记录
+	undoStack       []operation                 // 可撤销的操作
+	redoStack       []operation                 // 已撤销、可重做的操作
+	journal         *journalFile                // 会话日志，每次操作后追加
+	journalErr      error                       // 写入会话日志遇到的第一个错误
+	salt            string                      // 承诺使用的盐，调用 Commit 后生成
+	commitment      string                      // 抽奖前公布的承诺值
 }
 
-func NewEngine(participants []model.Participant, prizes []model.Prize) *Engine {
-	eligibleMap := make(map[int]model.Participant)
+// NewEngine 创建抽奖引擎，seed 决定抽奖的全部随机性。
+// ID 重复的参与者只保留第一次出现的记录，见 DedupeParticipants
+func NewEngine(participants []model.Participant, prizes []model.Prize, seed int64) *Engine {
+	participants = DedupeParticipants(participants)
+	eligibleMap := make(map[int]model.Participant, len(participants))
+	eligibleIDs := make([]int, 0, len(participants))
 	for _, p := range participants {
 		eligibleMap[p.ID] = p
+		eligibleIDs = append(eligibleIDs, p.ID)
+	}
+	slices.Sort(eligibleIDs)
+	batchSizes := make(map[int]int, len(prizes))
+	for _, p := range prizes {
+		batchSizes[p.ID] = max(p.BatchSize, 0)
 	}
 
 	return &Engine{
 		allParticipants: participants,
-		prizes:          prizes,
+		prizes:          slices.Clone(prizes), // 引擎会修改 DrawnCount，不影响调用方的切片
 		eligible:        eligibleMap,
+		eligibleIDs:     eligibleIDs,
+		eligibleCounts:  make(map[int]eligibleCount),
 		allWinners:      make(map[int][]model.Participant),
+		batchSizes:      batchSizes,
+		seed:            seed,
+		animRng:         rand.New(rand.NewSource(seed ^ 0x5DEECE66D)),
+		weights:         DefaultWeightStrategy(),
+		clock:           time.Now,
 	}
 }
 
-// Draw 为指定奖项抽出中奖者
-func (e *Engine) Draw(prizeID int) ([]model.Participant, bool) {
-	var prizeToDraw *model.Prize
-	prizeIndex := -1
-	for i, p := range e.prizes {
-		if p.ID == prizeID {
-			prizeToDraw = &e.prizes[i]
-			prizeIndex = i
-			break
+// DedupeParticipants 去掉 ID 重复的参与者，每个 ID 只保留第一次出现的记录。
+// 抽奖、复核和模拟都按同一规则处理名单，名单摘要因此保持一致；不修改原名单
+func DedupeParticipants(participants []model.Participant) []model.Participant {
+	seen := make(map[int]bool, len(participants))
+	kept := make([]model.Participant, 0, len(participants))
+	for _, p := range participants {
+		if seen[p.ID] {
+			continue
 		}
+		seen[p.ID] = true
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// PriorHistory 返回只保留 eventYear 之前中奖记录的名单副本，不修改原名单。
+// 本届保存的中奖结果会在重新读取名单时出现在中奖历史中，去掉后本届重新开始的抽奖
+// 不会降低本届中奖者的权重，赛后复核读取的名单也与抽奖前公布承诺时一致
+func PriorHistory(participants []model.Participant, eventYear int) []model.Participant {
+	prior := slices.Clone(participants)
+	for i, p := range prior {
+		if !slices.ContainsFunc(p.WinningHistory, func(r model.WinningRecord) bool { return r.Year >= eventYear }) {
+			continue
+		}
+		prior[i].WinningHistory = slices.DeleteFunc(slices.Clone(p.WinningHistory), func(r model.WinningRecord) bool {
+			return r.Year >= eventYear
+		})
+	}
+	return prior
+}
+
+// SetClock 设置引擎使用的时钟，用于日志和导出的时间戳，未设置活动年份时也决定活动年份
+func (e *Engine) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	e.clock = now
+}
+
+// Now 返回引擎时钟的当前时间
+func (e *Engine) Now() time.Time {
+	return e.clock()
+}
+
+// SetEventYear 设置活动所属的年份，例如年初举办的上一财年年会。
+// 往年中奖记录按距该年份的年数衰减；0 表示使用时钟的当前年份。必须在 Commit 和第一次抽奖前调用
+func (e *Engine) SetEventYear(year int) {
+	e.eventYear = max(year, 0)
+}
+
+// EventYear 返回活动所属的年份，未设置时为时钟的当前年份
+func (e *Engine) EventYear() int {
+	if e.eventYear > 0 {
+		return e.eventYear
 	}
-	if prizeToDraw == nil {
-		return nil, false // 奖项不存在
+	return e.clock().Year()
+}
+
+// SetWeightStrategy 设置权重策略，必须在 Commit 和第一次抽奖前调用
+func (e *Engine) SetWeightStrategy(ws WeightStrategy) {
+	if ws == nil {
+		ws = DefaultWeightStrategy()
+	}
+	e.weights = ws
+}
+
+// WeightStrategy 返回当前使用的权重策略
+func (e *Engine) WeightStrategy() WeightStrategy {
+	return e.weights
+}
+
+// NewSeed 生成一个新的随机种子
+func NewSeed() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) &^ (1 << 63))
+}
+
+// Seed 返回引擎使用的随机种子
+func (e *Engine) Seed() int64 {
+	return e.seed
+}
+
+// 抽奖失败的原因
+var (
+	ErrPrizeNotFound   = errors.New("奖项不存在")
+	ErrPrizeFullyDrawn = errors.New("该奖项名额已抽完")
+	ErrNoCandidates    = errors.New("没有可抽奖的候选人")
+	ErrInvalidBatch    = errors.New("每批人数不能为负数")
+)
+
+// Draw 为指定奖项抽出一批中奖者，人数由 NextBatch 决定。
+// 只从满足参与条件的人中抽取，配额无法满足时返回 *QuotaError 说明原因
+func (e *Engine) Draw(prizeID int) ([]model.Participant, error) {
+	return e.drawBatch(prizeID, e.NextBatch(prizeID))
+}
+
+// drawBatch 为指定奖项抽出 count 名中奖者，count 超过剩余名额时只抽剩余名额
+func (e *Engine) drawBatch(prizeID, count int) ([]model.Participant, error) {
+	prizeIndex := e.prizeIndex(prizeID)
+	if prizeIndex < 0 {
+		return nil, ErrPrizeNotFound
 	}
+	prizeToDraw := e.prizes[prizeIndex]
 
 	// 如果该奖项名额已满，则不允许再抽
 	if prizeToDraw.DrawnCount >= prizeToDraw.Count {
-		return nil, false
+		return nil, ErrPrizeFullyDrawn
 	}
 
 	// 确定本次需要抽取的人数
 	drawCount := prizeToDraw.Count - prizeToDraw.DrawnCount
+	if count > 0 {
+		drawCount = min(count, drawCount)
+	}
+
+	seq := len(e.events)
+	winners, err := e.pick(prizeToDraw, drawCount, e.drawRand(seq, prizeID))
+	if err != nil {
+		return nil, err
+	}
+
+	e.do(Event{Op: OpDraw, PrizeID: prizeID, Count: drawCount, WinnerIDs: participantIDs(winners)},
+		func() { e.applyDraw(prizeIndex, winners) },
+		func() { e.undoDraw(prizeIndex, winners) })
+
+	return winners, nil
+}
+
+// BatchSize 返回奖项每次抽取的人数，0 表示一次抽完剩余名额
+func (e *Engine) BatchSize(prizeID int) int {
+	return e.batchSizes[prizeID]
+}
+
+// SetBatchSize 设置奖项每次抽取的人数，0 表示一次抽完剩余名额。
+// 每批人数只影响之后的抽奖，实际抽取的人数会记录在操作日志中，不影响复核
+func (e *Engine) SetBatchSize(prizeID, size int) error {
+	if e.prizeIndex(prizeID) < 0 {
+		return ErrPrizeNotFound
+	}
+	if size < 0 {
+		return ErrInvalidBatch
+	}
+	e.batchSizes[prizeID] = size
+	return nil
+}
+
+// NextBatch 返回下一次抽取该奖项时会抽出的人数，名额已满时返回 0
+func (e *Engine) NextBatch(prizeID int) int {
+	i := e.prizeIndex(prizeID)
+	if i < 0 {
+		return 0
+	}
+	remaining := max(e.prizes[i].Count-e.prizes[i].DrawnCount, 0)
+	if size := e.batchSizes[prizeID]; size > 0 {
+		return min(size, remaining)
+	}
+	return remaining
+}
+
+// Finished 是否所有奖项都已抽完。还有名额、但已没有符合条件的候选人的奖项无法再抽，也算抽完
+func (e *Engine) Finished() bool {
+	for _, p := range e.prizes {
+		if p.DrawnCount >= p.Count {
+			continue
+		}
+		if n, err := e.EligibleCount(p.ID); err != nil || n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// pick 使用给定的随机源从候选池中抽出 count 名中奖者，不修改引擎状态
+func (e *Engine) pick(prize model.Prize, count int, rng *rand.Rand) ([]model.Participant, error) {
+	quotas, err := ParseQuotas(prize.Quotas)
+	if err != nil {
+		return nil, err
+	}
 
 	// 构造权重选择器
-	choices := e.getWeightedChoices(*prizeToDraw)
+	choices, err := e.getWeightedChoices(prize)
+	if err != nil {
+		return nil, err
+	}
 	if len(choices) == 0 {
-		return nil, false // 没有可抽奖的人了
+		return nil, ErrNoCandidates
+	}
+	if len(quotas) > 0 {
+		return pickWithQuotas(prize, quotas, choices, e.allWinners[prize.ID], count, prize.Count-prize.DrawnCount, rng)
 	}
+
 	// 如果候选人数少于等于要抽取的人数，则全部中奖
-	if len(choices) <= drawCount {
+	if len(choices) <= count {
 		winners := make([]model.Participant, 0, len(choices))
 		for _, choice := range choices {
-			winners = append(winners, choice.Item.(model.Participant))
-		}
-		// 更新状态
-		for _, winner := range winners {
-			delete(e.eligible, winner.ID)
+			winners = append(winners, choice.Participant)
 		}
-		e.allWinners[prizeToDraw.ID] = append(e.allWinners[prizeToDraw.ID], winners...)
-		e.prizes[prizeIndex].DrawnCount += len(winners)
-
-		return winners, true
+		return winners, nil
 	}
 
-	// 如果候选人多于要抽取的人数，则开始抽奖
-	chooser, _ := weightedrand.NewChooser(choices...)
-	winnersMap := make(map[int]model.Participant) // 使用 map 来确保中奖者不重复
+	// 如果候选人多于要抽取的人数，则按权重不放回地抽出 count 人，按抽中顺序记录
+	return sampleWithoutReplacement(choices, count, rng), nil
+}
+
+// applyDraw 将中奖者移出候选池，并更新中奖记录和奖品已抽取数量
+func (e *Engine) applyDraw(prizeIndex int, winners []model.Participant) {
+	e.removeEligible(winners...)
+	prizeID := e.prizes[prizeIndex].ID
+	e.allWinners[prizeID] = append(e.allWinners[prizeID], winners...)
+	e.prizes[prizeIndex].DrawnCount += len(winners)
+}
 
-	// 循环抽奖，直到抽满 drawCount 个不重复的中奖者
-	for len(winnersMap) < drawCount {
-		winner := chooser.Pick().(model.Participant)
-		winnersMap[winner.ID] = winner
+// undoDraw 撤销 applyDraw：去掉最后抽出的这批中奖者并放回候选池
+func (e *Engine) undoDraw(prizeIndex int, winners []model.Participant) {
+	prizeID := e.prizes[prizeIndex].ID
+	kept := len(e.allWinners[prizeID]) - len(winners)
+	if kept > 0 {
+		e.allWinners[prizeID] = e.allWinners[prizeID][:kept:kept]
+	} else {
+		delete(e.allWinners, prizeID)
 	}
+	e.addEligible(winners...)
+	e.prizes[prizeIndex].DrawnCount -= len(winners)
+}
 
-	var currentWinners []model.Participant
-	for _, winner := range winnersMap {
-		currentWinners = append(currentWinners, winner)
-		// 从总候选池中移除中奖者
-		delete(e.eligible, winner.ID)
+// drawRand 返回第 seq 次操作抽取 prizeID 时使用的随机源，只由种子派生
+func (e *Engine) drawRand(seq, prizeID int) *rand.Rand {
+	return rand.New(rand.NewSource(fairness.DrawSeed(e.seed, seq, prizeID)))
+}
+
+// prizeIndex 返回奖项在列表中的下标，不存在时返回 -1
+func (e *Engine) prizeIndex(prizeID int) int {
+	for i, p := range e.prizes {
+		if p.ID == prizeID {
+			return i
+		}
 	}
+	return -1
+}
 
-	// 更新中奖记录和奖品已抽取数量
-	e.allWinners[prizeToDraw.ID] = append(e.allWinners[prizeToDraw.ID], currentWinners...)
-	e.prizes[prizeIndex].DrawnCount += len(currentWinners)
+// addEligible 将参与者放回候选池。一次放回多人时合并到有序的 ID 列表中，
+// 耗时与候选池大小成线性关系
+func (e *Engine) addEligible(participants ...model.Participant) {
+	e.poolVersion++
+	var added []int
+	for _, p := range participants {
+		if _, ok := e.eligible[p.ID]; !ok {
+			added = append(added, p.ID)
+		}
+		e.eligible[p.ID] = p
+	}
+	switch len(added) {
+	case 0:
+	case 1:
+		i, _ := slices.BinarySearch(e.eligibleIDs, added[0])
+		e.eligibleIDs = slices.Insert(e.eligibleIDs, i, added[0])
+	default:
+		slices.Sort(added)
+		merged := make([]int, 0, len(e.eligibleIDs)+len(added))
+		i, j := 0, 0
+		for i < len(e.eligibleIDs) && j < len(added) {
+			if e.eligibleIDs[i] < added[j] {
+				merged = append(merged, e.eligibleIDs[i])
+				i++
+			} else {
+				merged = append(merged, added[j])
+				j++
+			}
+		}
+		merged = append(merged, e.eligibleIDs[i:]...)
+		e.eligibleIDs = append(merged, added[j:]...)
+	}
+}
 
-	return currentWinners, true
+// removeEligible 将参与者移出候选池，一次移出多人时只遍历一次有序的 ID 列表
+func (e *Engine) removeEligible(participants ...model.Participant) {
+	e.poolVersion++
+	if len(participants) == 1 {
+		if i, found := slices.BinarySearch(e.eligibleIDs, participants[0].ID); found {
+			e.eligibleIDs = slices.Delete(e.eligibleIDs, i, i+1)
+		}
+	} else if len(participants) > 1 {
+		removed := make(map[int]bool, len(participants))
+		for _, p := range participants {
+			removed[p.ID] = true
+		}
+		e.eligibleIDs = slices.DeleteFunc(e.eligibleIDs, func(id int) bool { return removed[id] })
+	}
+	for _, p := range participants {
+		delete(e.eligible, p.ID)
+	}
 }
 
-// GetEligibleParticipants 获取当前所有有资格的参与者
+// GetEligibleParticipants 获取当前所有有资格的参与者，按 ID 排序以保证结果可复现
 func (e *Engine) GetEligibleParticipants() []model.Participant {
-	var participants []model.Participant
-	for _, p := range e.eligible {
-		participants = append(participants, p)
+	participants := make([]model.Participant, 0, len(e.eligibleIDs))
+	for _, id := range e.eligibleIDs {
+		participants = append(participants, e.eligible[id])
 	}
 	return participants
 }
 
-// getWeightedChoices 为当前有资格的参与者生成加权选项
-func (e *Engine) getWeightedChoices(prize model.Prize) []weightedrand.Choice {
-	currentYear := time.Now().Year()
-	var choices []weightedrand.Choice
+// candidates 返回尚未中奖且满足奖项参与条件的参与者，按 ID 排序
+func (e *Engine) candidates(prize model.Prize) ([]model.Participant, error) {
+	rules, err := ParseEligibilityRules(prize.Eligibility)
+	if err != nil {
+		return nil, err
+	}
+	eligible := e.GetEligibleParticipants()
+	if len(rules) == 0 {
+		return eligible, nil
+	}
+	return slices.DeleteFunc(eligible, func(p model.Participant) bool {
+		return !matchAll(rules, p)
+	}), nil
+}
+
+// eligibleCount 缓存的预览人数及计算时候选池的版本
+type eligibleCount struct {
+	count       int
+	poolVersion int
+}
 
+// EligibleCount 返回当前可以抽取指定奖项的人数，用于抽奖前预览。
+// 界面每次刷新都会调用，因此只计数，并缓存到候选池下一次变化
+func (e *Engine) EligibleCount(prizeID int) (int, error) {
+	i := e.prizeIndex(prizeID)
+	if i < 0 {
+		return 0, ErrPrizeNotFound
+	}
+	if cached, ok := e.eligibleCounts[prizeID]; ok && cached.poolVersion == e.poolVersion {
+		return cached.count, nil
+	}
+	rules, err := ParseEligibilityRules(e.prizes[i].Eligibility)
+	if err != nil {
+		return 0, err
+	}
+	count := len(e.eligible)
+	if len(rules) > 0 {
+		count = 0
+		for _, p := range e.eligible {
+			if matchAll(rules, p) {
+				count++
+			}
+		}
+	}
+	e.eligibleCounts[prizeID] = eligibleCount{count: count, poolVersion: e.poolVersion}
+	return count, nil
+}
+
+// getWeightedChoices 为满足奖项参与条件的参与者生成加权选项
+func (e *Engine) getWeightedChoices(prize model.Prize) ([]weightedChoice, error) {
+	eventYear := e.EventYear()
+	var choices []weightedChoice
+
+	// map 的遍历顺序是随机的，必须按固定顺序构造选项，相同种子才能抽出相同结果
+	eligible, err := e.candidates(prize)
+	if err != nil {
+		return nil, err
+	}
 	// 如果候选人池为空，直接返回
-	if len(e.eligible) == 0 {
-		return choices
+	if len(eligible) == 0 {
+		return choices, nil
 	}
 
-	for _, participant := range e.eligible {
-		// 乘以1000以提高权重计算的精度
-		weight := uint(calculateWeight(participant, currentYear) * prize.Probability * 1000)
+	for _, participant := range eligible {
+		weight := e.weights.Weight(participant, prize, eventYear) * prize.Probability
+		if participant.Weight > 0 {
+			weight *= participant.Weight
+		}
 		if weight > 0 {
-			choices = append(choices, weightedrand.Choice{Item: participant, Weight: weight})
+			choices = append(choices, weightedChoice{Participant: participant, Weight: weight})
 		}
 	}
 	// 如果计算后所有人的权重都是0，则给予每个人相同的权重
 	if len(choices) == 0 {
-		for _, participant := range e.eligible {
-			choices = append(choices, weightedrand.Choice{Item: participant, Weight: 100})
+		for _, participant := range eligible {
+			choices = append(choices, weightedChoice{Participant: participant, Weight: 1})
 		}
 	}
 
-	return choices
+	return choices, nil
 }
 
-// calculateWeight 计算参与者的最终抽奖权重 (此函数逻辑来自你的版本)
+// calculateWeight 使用默认的历史衰减策略计算参与者的抽奖权重
 func calculateWeight(participant model.Participant, currentYear int) float64 {
-	baseWeight := 1.0
-	decayFactor := 0.5        // 衰减因子
-	levelPenaltyFactor := 1.5 // 等级惩罚因子
-
-	for _, record := range participant.WinningHistory {
-		yearDiff := float64(currentYear - record.Year)
-		timePenalty := math.Exp(-decayFactor * yearDiff)                           // 时间惩罚
-		levelPenalty := math.Pow(levelPenaltyFactor, float64(5-record.PrizeLevel)) // 等级惩罚
-		baseWeight -= timePenalty * levelPenalty
-	}
-
-	if baseWeight < 0.01 {
-		return 0.01 // 保证最低权重
-	}
-	return baseWeight
+	return DefaultWeightStrategy().Weight(participant, model.Prize{}, currentYear)
 }
 
 // GetPrizes 返回奖品列表
@@ -167,36 +472,82 @@ func (e *Engine) GetAllWinners() map[int][]model.Participant {
 
 // ResetPrize 重置某个奖项，使其可以重新抽取
 func (e *Engine) ResetPrize(prizeID int) {
-	// 1. 将该奖项的中奖者放回 eligible 池
-	if winners, ok := e.allWinners[prizeID]; ok {
-		for _, winner := range winners {
-			e.eligible[winner.ID] = winner
+	var reset resetDelta
+	e.do(Event{Op: OpReset, PrizeID: prizeID},
+		func() { reset = e.applyReset(prizeID) },
+		func() { e.undoReset(prizeID, reset) })
+}
+
+// resetDelta 重置奖项时清除的状态，用于撤销
+type resetDelta struct {
+	winners    []model.Participant
+	forfeits   []ForfeitRecord // 被删除的弃奖记录
+	positions  []int           // 被删除的弃奖记录在 forfeits 中的位置，升序
+	drawnCount int
+}
+
+// applyReset 将奖项的中奖者放回候选池并清空中奖记录，返回被清除的状态
+func (e *Engine) applyReset(prizeID int) resetDelta {
+	var reset resetDelta
+
+	// 1. 将该奖项的中奖者以及被取消资格的弃奖者放回 eligible 池
+	reset.winners = e.allWinners[prizeID]
+	e.addEligible(reset.winners...)
+	kept := e.forfeits[:0:0]
+	for i, f := range e.forfeits {
+		if f.PrizeID != prizeID {
+			kept = append(kept, f)
+			continue
+		}
+		if f.Excluded {
+			e.addEligible(f.Participant)
 		}
+		reset.forfeits = append(reset.forfeits, f)
+		reset.positions = append(reset.positions, i)
+	}
+	if reset.forfeits != nil {
+		e.forfeits = kept
 	}
 
 	// 2. 清空该奖项的中奖记录
 	delete(e.allWinners, prizeID)
 
 	// 3. 重置奖品的 DrawnCount
-	for i := range e.prizes {
-		if e.prizes[i].ID == prizeID {
-			e.prizes[i].DrawnCount = 0
-			break
+	if i := e.prizeIndex(prizeID); i >= 0 {
+		reset.drawnCount = e.prizes[i].DrawnCount
+		e.prizes[i].DrawnCount = 0
+	}
+	return reset
+}
+
+// undoReset 撤销 applyReset：恢复中奖者、弃奖记录和已抽取数量，并将他们移出候选池
+func (e *Engine) undoReset(prizeID int, reset resetDelta) {
+	if reset.winners != nil {
+		e.allWinners[prizeID] = reset.winners
+	}
+	e.removeEligible(reset.winners...)
+	for j, f := range reset.forfeits {
+		if f.Excluded {
+			e.removeEligible(f.Participant)
 		}
+		e.forfeits = slices.Insert(e.forfeits, reset.positions[j], f)
+	}
+	if i := e.prizeIndex(prizeID); i >= 0 {
+		e.prizes[i].DrawnCount = reset.drawnCount
 	}
 }
 
-// GetRandomNames 从有资格的参与者中随机挑选N个名字用于动画
+// GetRandomNames 从有资格的参与者中随机挑选N个名字用于动画。
+// 动画每帧都会调用，直接从有序的 ID 列表中取样，不复制候选池
 func (e *Engine) GetRandomNames(count int) []string {
-	eligible := e.GetEligibleParticipants()
-	if len(eligible) == 0 {
+	if len(e.eligibleIDs) == 0 {
 		return []string{"无候选人"}
 	}
 
 	names := make([]string, count)
 	for i := range count {
-		randIndex := rand.Intn(len(eligible))
-		names[i] = eligible[randIndex].Name
+		randIndex := e.animRng.Intn(len(e.eligibleIDs))
+		names[i] = e.eligible[e.eligibleIDs[randIndex]].Name
 	}
 	return names
 }
@@ -2,6 +2,8 @@ package lottery
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -82,6 +84,19 @@ func createTestPrizes() []model.Prize {
 	}
 }
 
+func TestDedupeParticipants(t *testing.T) {
+	participants := []model.Participant{{ID: 1, Name: "张三"}, {ID: 2, Name: "李四"}, {ID: 1, Name: "王五"}}
+	kept := DedupeParticipants(participants)
+	require.Len(t, kept, 2)
+	assert.Equal(t, "张三", kept[0].Name, "保留第一次出现的记录")
+	assert.Equal(t, "李四", kept[1].Name)
+	assert.Len(t, participants, 3, "不修改原名单")
+
+	eligible := NewEngine(participants, nil, 1).GetEligibleParticipants()
+	require.Len(t, eligible, 2, "引擎按同一规则去重")
+	assert.Equal(t, "张三", eligible[0].Name)
+}
+
 func TestEngine_Draw(t *testing.T) {
 	testCases := []struct {
 		name              string
@@ -138,16 +153,16 @@ func TestEngine_Draw(t *testing.T) {
 			// 每个测试用例都使用全新的Engine实例
 			participants := createTestParticipants(tc.participantsCount)
 			prizes := createTestPrizes()
-			engine := NewEngine(participants, prizes)
+			engine := NewEngine(participants, prizes, 42)
 
-			winners, ok := engine.Draw(tc.prizeIDToDraw)
+			winners, err := engine.Draw(tc.prizeIDToDraw)
 
 			// 使用 testify/assert 进行断言
-			assert.Equal(t, tc.expectSuccess, ok)
+			assert.Equal(t, tc.expectSuccess, err == nil)
 			assert.Len(t, winners, tc.expectedWinCount, "中奖人数应该符合预期")
 			assert.Len(t, engine.eligible, tc.expectedEligible, "剩余候选人数应该符合预期")
 
-			if ok {
+			if err == nil {
 				// 验证中奖者确实从候选池中移除了
 				for _, winner := range winners {
 					_, exists := engine.eligible[winner.ID]
@@ -158,8 +173,8 @@ func TestEngine_Draw(t *testing.T) {
 			// 特殊处理“连续抽取”用例
 			if tc.name == "连续抽取" {
 				// 接着抽二等奖
-				winners2, ok2 := engine.Draw(2)
-				assert.True(t, ok2)
+				winners2, err2 := engine.Draw(2)
+				assert.NoError(t, err2)
 				assert.Len(t, winners2, 3)
 				assert.Len(t, engine.eligible, 16) // 19 - 3
 			}
@@ -171,11 +186,11 @@ func TestEngine_ResetPrize(t *testing.T) {
 	// 1. Setup: 创建一个有10个参与者的引擎
 	participants := createTestParticipants(10)
 	prizes := createTestPrizes()
-	engine := NewEngine(participants, prizes)
+	engine := NewEngine(participants, prizes, 42)
 
 	// 2. Action: 抽取二等奖 (3名)
-	winners, ok := engine.Draw(2)
-	require.True(t, ok) // require 会在失败时立即停止测试，适合前置条件
+	winners, err := engine.Draw(2)
+	require.NoError(t, err) // require 会在失败时立即停止测试，适合前置条件
 	require.Len(t, winners, 3)
 	require.Len(t, engine.eligible, 7)
 	require.Equal(t, 3, engine.prizes[1].DrawnCount) // 假设prizes[1]是二等奖
@@ -197,16 +212,96 @@ func TestEngine_ResetPrize(t *testing.T) {
 	assert.Equal(t, 0, prize2_reset.DrawnCount, "奖品的已抽取数量应重置为0")
 
 	// 验证 allWinners map 中已无该奖项记录
-	_, ok = engine.allWinners[2]
+	_, ok := engine.allWinners[2]
 	assert.False(t, ok, "总中奖名单中不应再有该奖项的记录")
 
 	// 5. 验证可以重新抽取该奖项
-	winners_after_reset, ok_after_reset := engine.Draw(2)
-	assert.True(t, ok_after_reset)
+	winners_after_reset, err_after_reset := engine.Draw(2)
+	assert.NoError(t, err_after_reset)
 	assert.Len(t, winners_after_reset, 3)
 	assert.Len(t, engine.eligible, 7)
 }
 
+func TestEngine_DrawInBatches(t *testing.T) {
+	prizes := []model.Prize{{ID: 1, Name: "阳光普照奖", Level: model.PrizeLevel3, Count: 12, Probability: 0.9, BatchSize: 5}}
+	engine := NewEngine(createTestParticipants(30), prizes, 1)
+
+	// 每批 5 人，最后一批只抽剩余的 2 人
+	var all []int
+	for _, expected := range []int{5, 5, 2} {
+		assert.Equal(t, expected, engine.NextBatch(1))
+		winners, err := engine.Draw(1)
+		require.NoError(t, err)
+		require.Len(t, winners, expected)
+		all = append(all, participantIDs(winners)...)
+		assert.Equal(t, len(all), engine.GetPrizes()[0].DrawnCount)
+	}
+	assert.Equal(t, all, participantIDs(engine.GetAllWinners()[1]), "各批中奖者应按顺序累加")
+	assert.Zero(t, engine.NextBatch(1))
+	_, err := engine.Draw(1)
+	assert.ErrorIs(t, err, ErrPrizeFullyDrawn)
+
+	counts := make([]int, 0, 3)
+	for _, ev := range engine.Events() {
+		counts = append(counts, ev.Count)
+	}
+	assert.Equal(t, []int{5, 5, 2}, counts, "每次抽奖应记录本批人数")
+}
+
+func TestEngine_SetBatchSize(t *testing.T) {
+	engine := NewEngine(createTestParticipants(30), createTestPrizes(), 1)
+	assert.Equal(t, 3, engine.NextBatch(2), "未设置每批人数时一次抽完")
+
+	require.NoError(t, engine.SetBatchSize(2, 2))
+	assert.Equal(t, 2, engine.BatchSize(2))
+	winners, err := engine.Draw(2)
+	require.NoError(t, err)
+	assert.Len(t, winners, 2)
+
+	// 调整每批人数不会被撤销
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	assert.Equal(t, 2, engine.BatchSize(2))
+
+	require.NoError(t, engine.SetBatchSize(2, 0))
+	assert.Equal(t, 3, engine.NextBatch(2))
+	assert.ErrorIs(t, engine.SetBatchSize(2, -1), ErrInvalidBatch)
+	assert.ErrorIs(t, engine.SetBatchSize(99, 1), ErrPrizeNotFound)
+}
+
+func TestEngine_DrawReproducible(t *testing.T) {
+	drawAll := func(seed int64) [][]int {
+		engine := NewEngine(createTestParticipants(50), createTestPrizes(), seed)
+		var results [][]int
+		for _, prize := range engine.GetPrizes() {
+			winners, err := engine.Draw(prize.ID)
+			require.NoError(t, err)
+			ids := make([]int, 0, len(winners))
+			for _, w := range winners {
+				ids = append(ids, w.ID)
+			}
+			results = append(results, ids)
+		}
+		return results
+	}
+
+	// 相同的参与者、奖品和种子，应按相同顺序抽出相同的中奖者
+	first := drawAll(20250101)
+	for range 5 {
+		assert.Equal(t, first, drawAll(20250101), "相同种子的抽奖结果应完全一致")
+	}
+
+	// 不同的种子应产生不同的结果
+	assert.NotEqual(t, first, drawAll(20250102), "不同种子的抽奖结果不应相同")
+
+	// 动画不应消耗抽奖随机源
+	engine := NewEngine(createTestParticipants(50), createTestPrizes(), 20250101)
+	engine.GetRandomNames(100)
+	winners, err := engine.Draw(1)
+	require.NoError(t, err)
+	assert.Equal(t, first[0][0], winners[0].ID, "动画不应影响抽奖结果")
+}
+
 func FuzzEngine_Draw(f *testing.F) {
 	// 1. 添加种子语料库 (seed corpus)
 	f.Add(100, 5, 10) // 100人，5个奖品，每个奖品10个名额
@@ -235,7 +330,7 @@ func FuzzEngine_Draw(f *testing.F) {
 			prizes[i] = model.Prize{ID: i, Name: fmt.Sprintf("FuzzPrize%d", i), Count: prizeCount}
 		}
 
-		engine := NewEngine(participants, prizes)
+		engine := NewEngine(participants, prizes, int64(numParticipants))
 
 		// 4. 执行并断言
 		// 循环抽取所有奖品
@@ -253,10 +348,10 @@ func FuzzEngine_Draw(f *testing.F) {
 				expectedDrawCount = currentEligibleCount
 			}
 
-			winners, ok := engine.Draw(prizes[i].ID)
+			winners, err := engine.Draw(prizes[i].ID)
 
 			// 断言本次抽奖的结果
-			assert.True(t, ok, "当有候选人时，抽奖应该返回 true")
+			assert.NoError(t, err, "当有候选人时，抽奖应该成功")
 			assert.Len(t, winners, expectedDrawCount, "抽出中奖者的人数应符合预期")
 
 			// 更新我们追踪的剩余人数
@@ -267,3 +362,110 @@ func FuzzEngine_Draw(f *testing.F) {
 		}
 	})
 }
+
+// BenchmarkEngine_Pick 抽样耗时应随候选人数线性增长，抽取人数接近候选人数时也不会变慢
+func BenchmarkEngine_Pick(b *testing.B) {
+	benchmarks := []struct {
+		participants int
+		count        int
+	}{
+		{participants: 1000, count: 500},
+		{participants: 10000, count: 5000},
+		{participants: 100000, count: 50000},
+		{participants: 100000, count: 10},
+		{participants: 100000, count: 99999},
+	}
+	for _, bm := range benchmarks {
+		b.Run(fmt.Sprintf("%d人抽%d人", bm.participants, bm.count), func(b *testing.B) {
+			prizes := []model.Prize{{ID: 1, Name: "阳光普照奖", Count: bm.count, Probability: 1}}
+			engine := NewEngine(createTestParticipants(bm.participants), prizes, 1)
+			prize := engine.GetPrizes()[0]
+			for b.Loop() {
+				if _, err := engine.pick(prize, bm.count, engine.drawRand(0, prize.ID)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEngine_Draw(b *testing.B) {
+	participants := createTestParticipants(100000)
+	prizes := []model.Prize{{ID: 1, Name: "阳光普照奖", Count: 90000, Probability: 1}}
+	for b.Loop() {
+		engine := NewEngine(participants, prizes, 1)
+		if _, err := engine.Draw(1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEngine_Preview 界面每帧调用的预览和动画不应随候选人数排序
+func BenchmarkEngine_Preview(b *testing.B) {
+	prizes := []model.Prize{{ID: 1, Name: "阳光普照奖", Count: 10, Probability: 1, Eligibility: []string{"id != 1"}}}
+	engine := NewEngine(createTestParticipants(100000), prizes, 1)
+	for b.Loop() {
+		if _, err := engine.EligibleCount(1); err != nil {
+			b.Fatal(err)
+		}
+		engine.GetRandomNames(10)
+	}
+}
+
+func TestEngine_EligibleIDsInSync(t *testing.T) {
+	engine := NewEngine(createTestParticipants(50), createTestPrizes(), 3)
+	inSync := func() {
+		t.Helper()
+		ids := slices.Sorted(maps.Keys(engine.eligible))
+		assert.Equal(t, ids, engine.eligibleIDs)
+		count, err := engine.EligibleCount(1)
+		require.NoError(t, err)
+		assert.Equal(t, len(ids), count)
+	}
+
+	third, err := engine.Draw(3)
+	require.NoError(t, err)
+	inSync()
+	_, err = engine.Forfeit(3, third[0].ID, false)
+	require.NoError(t, err)
+	inSync()
+	_, err = engine.Forfeit(3, third[1].ID, true)
+	require.NoError(t, err)
+	inSync()
+	engine.ResetPrize(3)
+	inSync()
+	assert.Len(t, engine.eligibleIDs, 50, "重置后所有人回到候选池")
+	for range 3 {
+		_, err = engine.Undo()
+		require.NoError(t, err)
+		inSync()
+	}
+}
+
+func TestEngine_Finished(t *testing.T) {
+	prizes := []model.Prize{
+		{ID: 1, Name: "一等奖", Count: 1, Probability: 1},
+		{ID: 2, Name: "特别奖", Count: 1, Probability: 1, Eligibility: []string{"id > 5"}},
+	}
+	engine := NewEngine(createTestParticipants(5), prizes, 1)
+	assert.False(t, engine.Finished())
+
+	_, err := engine.Draw(1)
+	require.NoError(t, err)
+	assert.True(t, engine.Finished(), "没有符合条件候选人的奖项无法再抽，也算抽完")
+
+	_, err = engine.Undo()
+	require.NoError(t, err)
+	assert.False(t, engine.Finished())
+}
+
+func TestPriorHistory(t *testing.T) {
+	participants := []model.Participant{
+		{ID: 1, Name: "张三", WinningHistory: []model.WinningRecord{{Year: 2023, PrizeLevel: 1}, {Year: 2025, PrizeLevel: 2}}},
+		{ID: 2, Name: "李四", WinningHistory: []model.WinningRecord{{Year: 2024, PrizeLevel: 3}}},
+	}
+	prior := PriorHistory(participants, 2025)
+	assert.Equal(t, []model.WinningRecord{{Year: 2023, PrizeLevel: 1}}, prior[0].WinningHistory, "去掉本届的中奖记录")
+	assert.Equal(t, participants[1].WinningHistory, prior[1].WinningHistory)
+	assert.Len(t, participants[0].WinningHistory, 2, "不修改原名单")
+}
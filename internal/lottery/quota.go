@@ -0,0 +1,255 @@
+package lottery
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// Quota 奖项的分组配额，例如 "department <= 2" 表示每个部门最多 2 人中奖，
+// "department >= 1" 表示候选人中出现的每个部门至少 1 人中奖（未填写部门的候选人不算一个分组），
+// 也可以按名单中的其他列分组，如 "attr.园区 <= 3"
+type Quota struct {
+	Field string // 分组字段
+	Max   bool   // true 为上限 (<=)，false 为下限 (>=)
+	Limit int
+}
+
+func (q Quota) String() string {
+	op := ">="
+	if q.Max {
+		op = "<="
+	}
+	return fmt.Sprintf("%s %s %d", q.Field, op, q.Limit)
+}
+
+var quotaPattern = regexp.MustCompile(`^\s*(` + fieldPattern + `)\s*(<=|>=)\s*(\d+)\s*$`)
+
+// ParseQuota 解析形如 "department <= 2" 的配额规则
+func ParseQuota(s string) (Quota, error) {
+	m := quotaPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Quota{}, fmt.Errorf("无效的配额规则 %q，格式应为 \"字段 <= 数量\" 或 \"字段 >= 数量\"", s)
+	}
+	field := strings.ToLower(m[1])
+	if _, ok := participantField(model.Participant{}, field); !ok {
+		return Quota{}, fmt.Errorf("配额规则 %q 引用了不支持的字段 %s", s, m[1])
+	}
+	limit, _ := strconv.Atoi(m[3]) // 正则已保证是数字
+	return Quota{Field: field, Max: m[2] == "<=", Limit: limit}, nil
+}
+
+// ParseQuotas 解析奖项上配置的全部配额规则
+func ParseQuotas(rules []string) ([]Quota, error) {
+	quotas := make([]Quota, 0, len(rules))
+	for _, rule := range rules {
+		q, err := ParseQuota(rule)
+		if err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, q)
+	}
+	return quotas, nil
+}
+
+// QuotaError 配额无法满足时返回的错误，说明具体原因
+type QuotaError struct {
+	PrizeName string
+	Reason    string
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("[%s] 的配额无法满足: %s", e.PrizeName, e.Reason)
+}
+
+// attributePrefix 引用参与者扩展属性的字段前缀，如 "attr.职级"
+const attributePrefix = "attr."
+
+// fieldPattern 规则中字段名的写法，扩展属性的名称可以包含非 ASCII 字符
+const fieldPattern = `\w+(?:\.[^\s<>=!]+)?`
+
+// participantField 返回参与者在分组字段上的取值，扩展属性不存在时取值为空
+func participantField(p model.Participant, field string) (string, bool) {
+	switch field {
+	case "department":
+		return p.Department, true
+	case "email":
+		return p.Email, true
+	default:
+		if name, ok := strings.CutPrefix(field, attributePrefix); ok && name != "" {
+			v, _ := p.Attribute(name)
+			return v, true
+		}
+		return "", false
+	}
+}
+
+// pickWithQuotas 在配额约束下抽出 count 名中奖者，existing 是该奖项此前各批次的中奖者，
+// slots 是该奖项剩余的全部名额（分批抽取时大于 count）。
+// 先为未达下限的分组补足人数，再从未达上限的分组中抽满剩余名额
+func pickWithQuotas(prize model.Prize, quotas []Quota, choices []weightedChoice, existing []model.Participant, count, slots int, rng *rand.Rand) ([]model.Participant, error) {
+	count = min(count, len(choices))
+	slots = max(min(slots, len(choices)), count)
+	fail := func(format string, args ...any) error {
+		return &QuotaError{PrizeName: prize.Name, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	// 统计每个分组的候选人数，以及此前批次已抽中的人数
+	groupSize := make(map[Quota]map[string]int)
+	taken := make(map[Quota]map[string]int)
+	for _, q := range quotas {
+		sizes := make(map[string]int)
+		for _, c := range choices {
+			v, _ := participantField(c.Participant, q.Field)
+			sizes[v]++
+		}
+		groupSize[q] = sizes
+		taken[q] = make(map[string]int)
+		for _, w := range existing {
+			v, _ := participantField(w, q.Field)
+			taken[q][v]++
+		}
+	}
+
+	// 上限：按配额最多能抽出的人数
+	for _, q := range quotas {
+		if !q.Max {
+			continue
+		}
+		capacity := 0
+		for v, n := range groupSize[q] {
+			capacity += max(min(n, q.Limit-taken[q][v]), 0)
+		}
+		if capacity < count {
+			return nil, fail("按 %s 最多只能抽出 %d 人，少于需要的 %d 人", q, capacity, count)
+		}
+	}
+
+	// 下限：各分组尚缺的人数之和不能超过剩余名额，且分组内候选人必须足够
+	required := 0
+	for _, q := range quotas {
+		if q.Max {
+			continue
+		}
+		for _, v := range minimumGroups(groupSize[q]) {
+			deficit := max(q.Limit-taken[q][v], 0)
+			if n := groupSize[q][v]; n < deficit {
+				return nil, fail("%s=%s 只有 %d 名候选人，无法满足 %s", q.Field, displayValue(v), n, q)
+			}
+			required += deficit
+		}
+	}
+	if required > slots {
+		return nil, fail("各分组下限合计还需要 %d 人，超过了剩余名额 %d 人", required, slots)
+	}
+
+	// 为全部候选人生成一次按权重的随机顺序。之后每次取某个范围内排在最前、且仍满足上限的人，
+	// 等价于每次按权重从该范围剩余的合格候选人中抽一人，但不必在每抽一人后重建候选池
+	order := sampleWithoutReplacement(choices, len(choices), rng)
+	winners := make([]model.Participant, 0, count)
+	picked := make(map[int]bool)
+
+	// allowed 判断候选人加入后是否仍满足所有上限
+	allowed := func(p model.Participant) bool {
+		if picked[p.ID] {
+			return false
+		}
+		for _, q := range quotas {
+			v, _ := participantField(p, q.Field)
+			if q.Max && taken[q][v] >= q.Limit {
+				return false
+			}
+		}
+		return true
+	}
+	// next 从 pool 的游标处向后找到第一个可以加入的候选人并抽中。
+	// 跳过的人已经中奖或所在分组已满，之后也不会再被抽中，因此游标只需向前移动
+	next := func(pool []model.Participant, cursor *int) bool {
+		for ; *cursor < len(pool); *cursor++ {
+			if winner := pool[*cursor]; allowed(winner) {
+				picked[winner.ID] = true
+				for _, q := range quotas {
+					v, _ := participantField(winner, q.Field)
+					taken[q][v]++
+				}
+				winners = append(winners, winner)
+				return true
+			}
+		}
+		return false
+	}
+
+	// 第一阶段：为未达下限的分组补足人数。每个缺少的名额是一个席位，席位按随机顺序补足，
+	// 本批名额不够时剩余的席位留给后续批次，不会总是排在前面的分组先补
+	type seat struct {
+		quota Quota
+		value string
+	}
+	var seats []seat
+	members := make(map[seat][]model.Participant) // 每个分组的候选人，按随机顺序
+	cursors := make(map[seat]int)
+	for _, q := range quotas {
+		if q.Max {
+			continue
+		}
+		for _, v := range minimumGroups(groupSize[q]) {
+			for range max(q.Limit-taken[q][v], 0) {
+				seats = append(seats, seat{quota: q, value: v})
+			}
+		}
+		for _, p := range order {
+			v, _ := participantField(p, q.Field)
+			members[seat{quota: q, value: v}] = append(members[seat{quota: q, value: v}], p)
+		}
+	}
+	rng.Shuffle(len(seats), func(i, j int) { seats[i], seats[j] = seats[j], seats[i] })
+	for _, s := range seats {
+		if len(winners) == count {
+			break
+		}
+		if taken[s.quota][s.value] >= s.quota.Limit {
+			continue // 已经因其他配额抽中的人而达到下限
+		}
+		cursor := cursors[s]
+		ok := next(members[s], &cursor)
+		cursors[s] = cursor
+		if !ok {
+			return nil, fail("%s=%s 受其他配额限制，无法满足 %s", s.quota.Field, displayValue(s.value), s.quota)
+		}
+	}
+
+	// 第二阶段：从所有未达上限的候选人中抽满剩余名额
+	cursor := 0
+	for len(winners) < count {
+		if !next(order, &cursor) {
+			return nil, fail("按配额只能抽出 %d 人，少于需要的 %d 人", len(winners), count)
+		}
+	}
+
+	return winners, nil
+}
+
+// sortedKeys 返回排序后的分组取值，保证相同种子下抽奖顺序一致
+func sortedKeys(m map[string]int) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// minimumGroups 返回需要满足下限的分组取值。字段为空的候选人缺少数据，不构成一个分组，
+// 不为其预留名额，但仍受上限约束
+func minimumGroups(m map[string]int) []string {
+	return slices.DeleteFunc(sortedKeys(m), func(v string) bool { return v == "" })
+}
+
+// displayValue 用于错误信息中显示分组取值，空值显示为“(空)”
+func displayValue(v string) string {
+	if v == "" {
+		return "(空)"
+	}
+	return v
+}
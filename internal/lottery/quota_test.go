@@ -0,0 +1,234 @@
+package lottery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// createDepartmentParticipants 按部门人数创建参与者，ID 从 1 开始连续编号
+func createDepartmentParticipants(sizes map[string]int, order ...string) []model.Participant {
+	var participants []model.Participant
+	for _, dept := range order {
+		for range sizes[dept] {
+			participants = append(participants, model.Participant{
+				ID:         len(participants) + 1,
+				Name:       dept,
+				Department: dept,
+			})
+		}
+	}
+	return participants
+}
+
+func countByDepartment(winners []model.Participant) map[string]int {
+	counts := make(map[string]int)
+	for _, w := range winners {
+		counts[w.Department]++
+	}
+	return counts
+}
+
+func TestParseQuota(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     string
+		expected Quota
+		wantErr  bool
+	}{
+		{name: "上限", rule: "department <= 2", expected: Quota{Field: "department", Max: true, Limit: 2}},
+		{name: "下限且不含空格", rule: "Department>=1", expected: Quota{Field: "department", Limit: 1}},
+		{name: "扩展属性", rule: "attr.园区 <= 3", expected: Quota{Field: "attr.园区", Max: true, Limit: 3}},
+		{name: "不支持的字段", rule: "office <= 2", wantErr: true},
+		{name: "不支持的运算符", rule: "department < 2", wantErr: true},
+		{name: "缺少数量", rule: "department <=", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := ParseQuota(tc.rule)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, q)
+		})
+	}
+}
+
+func TestEngine_DrawWithQuotas(t *testing.T) {
+	sizes := map[string]int{"技术部": 20, "市场部": 3, "财务部": 2}
+	participants := createDepartmentParticipants(sizes, "技术部", "市场部", "财务部")
+
+	testCases := []struct {
+		name   string
+		quotas []string
+		count  int
+		check  func(t *testing.T, counts map[string]int)
+	}{
+		{
+			name:   "每个部门最多 2 人",
+			quotas: []string{"department <= 2"},
+			count:  6,
+			check: func(t *testing.T, counts map[string]int) {
+				for dept, n := range counts {
+					assert.LessOrEqual(t, n, 2, "%s 中奖人数超出上限", dept)
+				}
+			},
+		},
+		{
+			name:   "每个部门至少 1 人",
+			quotas: []string{"department >= 1"},
+			count:  3,
+			check: func(t *testing.T, counts map[string]int) {
+				for dept := range sizes {
+					assert.Equal(t, 1, counts[dept], "%s 应恰好有 1 人中奖", dept)
+				}
+			},
+		},
+		{
+			name:   "上下限同时生效",
+			quotas: []string{"department >= 1", "department <= 4"},
+			count:  8,
+			check: func(t *testing.T, counts map[string]int) {
+				for dept := range sizes {
+					assert.GreaterOrEqual(t, counts[dept], 1, "%s 至少应有 1 人中奖", dept)
+					assert.LessOrEqual(t, counts[dept], 4, "%s 中奖人数超出上限", dept)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// 多个种子下约束都应成立
+			for seed := range int64(20) {
+				prizes := []model.Prize{{ID: 1, Name: "购物卡", Level: model.PrizeLevel3, Count: tc.count, Probability: 0.9, Quotas: tc.quotas}}
+				engine := NewEngine(participants, prizes, seed)
+
+				winners, err := engine.Draw(1)
+				require.NoError(t, err)
+				require.Len(t, winners, tc.count, "应抽满全部名额")
+				tc.check(t, countByDepartment(winners))
+			}
+		})
+	}
+}
+
+func TestEngine_DrawWithQuotasReproducible(t *testing.T) {
+	participants := createDepartmentParticipants(map[string]int{"技术部": 10, "市场部": 10}, "技术部", "市场部")
+	prizes := []model.Prize{{ID: 1, Name: "购物卡", Level: model.PrizeLevel3, Count: 4, Probability: 0.9, Quotas: []string{"department <= 2"}}}
+
+	first, err := NewEngine(participants, prizes, 7).Draw(1)
+	require.NoError(t, err)
+	second, err := NewEngine(participants, prizes, 7).Draw(1)
+	require.NoError(t, err)
+	assert.Equal(t, first, second, "相同种子下配额抽奖结果应一致")
+}
+
+func TestEngine_DrawWithQuotasInfeasible(t *testing.T) {
+	participants := createDepartmentParticipants(map[string]int{"技术部": 20, "市场部": 1}, "技术部", "市场部")
+
+	testCases := []struct {
+		name   string
+		quotas []string
+		count  int
+	}{
+		{name: "上限合计不足名额", quotas: []string{"department <= 2"}, count: 5},
+		{name: "部门人数不足下限", quotas: []string{"department >= 2"}, count: 4},
+		{name: "下限合计超过名额", quotas: []string{"department >= 1"}, count: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prizes := []model.Prize{{ID: 1, Name: "购物卡", Level: model.PrizeLevel3, Count: tc.count, Probability: 0.9, Quotas: tc.quotas}}
+			engine := NewEngine(participants, prizes, 1)
+
+			winners, err := engine.Draw(1)
+			var quotaErr *QuotaError
+			require.ErrorAs(t, err, &quotaErr)
+			assert.Equal(t, "购物卡", quotaErr.PrizeName)
+			assert.NotEmpty(t, quotaErr.Reason)
+			assert.Nil(t, winners)
+
+			// 失败的抽奖不应改变引擎状态
+			assert.Zero(t, engine.GetPrizes()[0].DrawnCount)
+			assert.Empty(t, engine.Events())
+		})
+	}
+}
+
+func TestEngine_DrawWithQuotasInBatches(t *testing.T) {
+	sizes := map[string]int{"技术部": 20, "市场部": 3, "财务部": 2}
+	participants := createDepartmentParticipants(sizes, "技术部", "市场部", "财务部")
+	prizes := []model.Prize{{ID: 1, Name: "购物卡", Level: model.PrizeLevel3, Count: 8, Probability: 0.9,
+		Quotas: []string{"department >= 1", "department <= 4"}, BatchSize: 2}}
+
+	// 配额按整个奖项计算，而不是每一批
+	for seed := range int64(20) {
+		engine := NewEngine(participants, prizes, seed)
+		for range 4 {
+			_, err := engine.Draw(1)
+			require.NoError(t, err)
+		}
+		counts := countByDepartment(engine.GetAllWinners()[1])
+		for dept := range sizes {
+			assert.GreaterOrEqual(t, counts[dept], 1, "%s 至少应有 1 人中奖", dept)
+			assert.LessOrEqual(t, counts[dept], 4, "%s 中奖人数超出上限", dept)
+		}
+	}
+}
+
+func TestEngine_DrawWithQuotasMinimumOrder(t *testing.T) {
+	sizes := map[string]int{"技术部": 5, "市场部": 5, "财务部": 5, "行政部": 5}
+	participants := createDepartmentParticipants(sizes, "技术部", "市场部", "财务部", "行政部")
+	prizes := []model.Prize{{ID: 1, Name: "购物卡", Level: model.PrizeLevel3, Count: 4, Probability: 0.9,
+		Quotas: []string{"department >= 1"}, BatchSize: 1}}
+
+	// 每批只抽一人时，先补足哪个部门是随机的，而不是总按部门名称的顺序
+	first := make(map[string]int)
+	for seed := range int64(200) {
+		winners, err := NewEngine(participants, prizes, seed).Draw(1)
+		require.NoError(t, err)
+		first[winners[0].Department]++
+	}
+	for dept := range sizes {
+		assert.Greater(t, first[dept], 20, "%s 应有机会在第一批中奖", dept)
+	}
+}
+
+func TestEngine_DrawWithQuotasBlankField(t *testing.T) {
+	participants := createDepartmentParticipants(map[string]int{"技术部": 5, "市场部": 5}, "技术部", "市场部")
+	participants = append(participants, model.Participant{ID: len(participants) + 1, Name: "未填部门"})
+
+	// 未填部门的人不构成一个分组，不占用下限名额
+	prizes := []model.Prize{{ID: 1, Name: "购物卡", Level: model.PrizeLevel3, Count: 2, Probability: 0.9,
+		Quotas: []string{"department >= 1"}}}
+	for seed := range int64(20) {
+		winners, err := NewEngine(participants, prizes, seed).Draw(1)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"技术部": 1, "市场部": 1}, countByDepartment(winners))
+	}
+
+	// 上限仍把未填部门的人算作一组
+	prizes[0].Count = 3
+	prizes[0].Quotas = []string{"department >= 1", "department <= 1"}
+	winners, err := NewEngine(participants, prizes, 1).Draw(1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"技术部": 1, "市场部": 1, "": 1}, countByDepartment(winners))
+}
+
+func BenchmarkEngine_DrawWithQuotas(b *testing.B) {
+	sizes := map[string]int{"技术部": 40000, "市场部": 30000, "财务部": 20000, "行政部": 10000}
+	participants := createDepartmentParticipants(sizes, "技术部", "市场部", "财务部", "行政部")
+	prizes := []model.Prize{{ID: 1, Name: "购物卡", Level: model.PrizeLevel3, Count: 1000, Probability: 0.9,
+		Quotas: []string{"department >= 100", "department <= 400"}}}
+	for b.Loop() {
+		if _, err := NewEngine(participants, prizes, 1).Draw(1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package lottery
+
+import (
+	"container/heap"
+	"math/rand"
+	"sort"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// weightedChoice 带抽奖权重的候选人
+type weightedChoice struct {
+	Participant model.Participant
+	Weight      float64 // 必须大于 0
+}
+
+// sampleWithoutReplacement 按权重不放回地抽出 k 名候选人，按抽中顺序排列。
+//
+// 使用 Efraimidis–Spirakis 指数键算法：为每个候选人生成键 -E/w（E 服从指数分布，w 为权重），
+// 键最大的 k 人即为中奖者，按键从大到小排列。结果的分布与“依次按权重抽取一人、抽中者不再放回”完全相同，
+// 但只需遍历一次候选人，时间复杂度为 O(n log k)，不会因重复抽中同一人而反复重试。
+func sampleWithoutReplacement(choices []weightedChoice, k int, rng *rand.Rand) []model.Participant {
+	k = min(k, len(choices))
+	if k <= 0 {
+		return nil
+	}
+
+	// 小顶堆保存当前键最大的 k 个候选人，堆顶是其中键最小的
+	h := make(keyHeap, 0, k)
+	for i, c := range choices {
+		key := -rng.ExpFloat64() / c.Weight
+		if len(h) < k {
+			heap.Push(&h, keyedChoice{key: key, index: i})
+		} else if key > h[0].key {
+			h[0] = keyedChoice{key: key, index: i}
+			heap.Fix(&h, 0)
+		}
+	}
+
+	sort.Slice(h, func(i, j int) bool { return h.Less(j, i) })
+	winners := make([]model.Participant, len(h))
+	for i, kc := range h {
+		winners[i] = choices[kc.index].Participant
+	}
+	return winners
+}
+
+// pickOne 按权重抽出一名候选人，choices 不能为空
+func pickOne(choices []weightedChoice, rng *rand.Rand) model.Participant {
+	return sampleWithoutReplacement(choices, 1, rng)[0]
+}
+
+// keyedChoice 候选人在 choices 中的下标及其随机键
+type keyedChoice struct {
+	key   float64
+	index int
+}
+
+// keyHeap 按键排序的小顶堆，键相同时下标大的先出堆，保证排序结果是确定的
+type keyHeap []keyedChoice
+
+func (h keyHeap) Len() int { return len(h) }
+func (h keyHeap) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key < h[j].key
+	}
+	return h[i].index > h[j].index
+}
+func (h keyHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *keyHeap) Push(x any)   { *h = append(*h, x.(keyedChoice)) }
+func (h *keyHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,76 @@
+package lottery
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+func TestSampleWithoutReplacement(t *testing.T) {
+	choices := make([]weightedChoice, 5)
+	for i := range choices {
+		choices[i] = weightedChoice{Participant: model.Participant{ID: i + 1}, Weight: float64(i + 1)}
+	}
+	rng := rand.New(rand.NewSource(1))
+
+	testCases := []struct {
+		name     string
+		k        int
+		expected int
+	}{
+		{name: "抽取部分候选人", k: 3, expected: 3},
+		{name: "抽取人数超过候选人数", k: 10, expected: 5},
+		{name: "抽取 0 人", k: 0, expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			winners := sampleWithoutReplacement(choices, tc.k, rng)
+			assert.Len(t, winners, tc.expected)
+			seen := make(map[int]bool)
+			for _, w := range winners {
+				assert.False(t, seen[w.ID], "中奖者不应重复")
+				seen[w.ID] = true
+			}
+		})
+	}
+
+	// 相同的随机源应得到相同的结果
+	first := sampleWithoutReplacement(choices, 3, rand.New(rand.NewSource(42)))
+	second := sampleWithoutReplacement(choices, 3, rand.New(rand.NewSource(42)))
+	assert.Equal(t, first, second)
+}
+
+// 抽样结果的分布应与“依次按权重抽取、抽中者不再放回”完全相同：
+// 第一名为 i、第二名为 j 的概率是 w_i/S × w_j/(S-w_i)
+func TestSampleWithoutReplacement_Distribution(t *testing.T) {
+	weights := []float64{1, 2, 3, 4}
+	choices := make([]weightedChoice, len(weights))
+	total := 0.0
+	for i, w := range weights {
+		choices[i] = weightedChoice{Participant: model.Participant{ID: i}, Weight: w}
+		total += w
+	}
+
+	const trials = 200000
+	rng := rand.New(rand.NewSource(20250101))
+	counts := make(map[[2]int]int)
+	for range trials {
+		winners := sampleWithoutReplacement(choices, 2, rng)
+		counts[[2]int{winners[0].ID, winners[1].ID}]++
+	}
+
+	// 标准差约为 0.001，容差取 0.005 可以稳定通过，同时能发现分布上的偏差
+	for i, wi := range weights {
+		for j, wj := range weights {
+			if i == j {
+				continue
+			}
+			expected := wi / total * wj / (total - wi)
+			actual := float64(counts[[2]int{i, j}]) / trials
+			assert.InDelta(t, expected, actual, 0.005, "先抽中 %d 再抽中 %d 的概率", i, j)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package lottery
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// MaxHistoryBucket 按最近一次中奖距今年数分组时的最大年数，更早的中奖合并到这一组
+const MaxHistoryBucket = 4
+
+// SimulateOptions 模拟抽奖的参数
+type SimulateOptions struct {
+	Runs      int              // 模拟的场次
+	Seed      int64            // 第 i 场使用 Seed+i 作为种子，相同的参数得到相同的结果
+	Weights   WeightStrategy   // 为 nil 时使用默认策略
+	EventYear int              // 活动所属年份，0 表示时钟的当前年份
+	Clock     func() time.Time // 模拟引擎使用的时钟，为 nil 时使用 time.Now
+}
+
+// ParticipantOdds 单个参与者的模拟结果
+type ParticipantOdds struct {
+	Participant model.Participant
+	Bucket      int         // 所属的中奖历史分组，见 HistoryBucket
+	Wins        map[int]int // 各奖项的中奖场次，Key 是 Prize.ID
+	AnyWins     int         // 中得任意奖项的场次
+}
+
+// BucketOdds 同一中奖历史分组内所有参与者的模拟结果之和
+type BucketOdds struct {
+	Bucket       int // 0 表示从未中奖，其余为最近一次中奖距今的年数
+	Participants int
+	Wins         map[int]int
+	AnyWins      int
+}
+
+// SimulationResult 模拟结果，概率 = 中奖场次 / 模拟场次
+type SimulationResult struct {
+	Runs         int
+	EventYear    int
+	Prizes       []model.Prize
+	Participants []ParticipantOdds // 与输入名单顺序一致
+	Buckets      []BucketOdds      // 按 Bucket 升序，只包含有参与者的分组
+}
+
+// Probability 返回参与者中得指定奖项的概率
+func (r SimulationResult) Probability(o ParticipantOdds, prizeID int) float64 {
+	return float64(o.Wins[prizeID]) / float64(r.Runs)
+}
+
+// AnyProbability 返回参与者中得任意奖项的概率
+func (r SimulationResult) AnyProbability(o ParticipantOdds) float64 {
+	return float64(o.AnyWins) / float64(r.Runs)
+}
+
+// BucketProbability 返回分组内平均每人中得指定奖项的概率
+func (r SimulationResult) BucketProbability(b BucketOdds, prizeID int) float64 {
+	return float64(b.Wins[prizeID]) / float64(r.Runs*b.Participants)
+}
+
+// BucketAnyProbability 返回分组内平均每人中得任意奖项的概率
+func (r SimulationResult) BucketAnyProbability(b BucketOdds) float64 {
+	return float64(b.AnyWins) / float64(r.Runs*b.Participants)
+}
+
+// HistoryBucket 返回参与者所属的中奖历史分组：0 表示从未中奖，
+// 否则为最近一次中奖距 eventYear 的年数，范围为 1 到 MaxHistoryBucket
+func HistoryBucket(p model.Participant, eventYear int) int {
+	if len(p.WinningHistory) == 0 {
+		return 0
+	}
+	latest := p.WinningHistory[0].Year
+	for _, r := range p.WinningHistory[1:] {
+		latest = max(latest, r.Year)
+	}
+	return max(1, min(eventYear-latest, MaxHistoryBucket))
+}
+
+// Simulate 使用真实的抽奖引擎反复模拟整场抽奖，统计每个人中得各奖项的概率。
+// 每场按奖品列表的顺序依次抽完所有奖项（遵守每批人数、参与条件和配额），用于活动前评估权重策略是否合理。
+func Simulate(participants []model.Participant, prizes []model.Prize, opts SimulateOptions) (SimulationResult, error) {
+	if opts.Runs <= 0 {
+		return SimulationResult{}, errors.New("模拟场次必须大于 0")
+	}
+	for _, p := range prizes {
+		if err := ValidatePrize(p); err != nil {
+			return SimulationResult{}, err
+		}
+	}
+
+	clock := opts.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	eventYear := opts.EventYear
+	if eventYear <= 0 {
+		eventYear = clock().Year()
+	}
+
+	// 与现场抽奖一致：重复的 ID 只保留第一条，忽略本届及以后的中奖记录
+	participants = PriorHistory(DedupeParticipants(participants), eventYear)
+	index := make(map[int]int, len(participants))
+	for i, p := range participants {
+		index[p.ID] = i
+	}
+
+	// 各场之间互不影响，分给多个 worker 并行模拟，最后汇总
+	workers := min(runtime.NumCPU(), opts.Runs)
+	totals := make([]map[int][]int, workers) // 每个 worker 的统计：奖项 ID → 每个参与者的中奖场次
+	anyTotals := make([][]int, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wins := make(map[int][]int, len(prizes))
+		for _, p := range prizes {
+			wins[p.ID] = make([]int, len(participants))
+		}
+		anyWins := make([]int, len(participants))
+		totals[w], anyTotals[w] = wins, anyWins
+
+		wg.Go(func() {
+			for run := w; run < opts.Runs; run += workers {
+				engine := NewEngine(participants, prizes, opts.Seed+int64(run))
+				engine.SetWeightStrategy(opts.Weights)
+				engine.SetClock(clock)
+				engine.SetEventYear(eventYear)
+				won := make(map[int]bool)
+				for _, prize := range prizes {
+					for engine.NextBatch(prize.ID) > 0 {
+						if _, err := engine.Draw(prize.ID); err != nil {
+							break // 候选人不足或配额无法满足时跳过剩余名额，与现场一致
+						}
+					}
+					for _, winner := range engine.GetAllWinners()[prize.ID] {
+						wins[prize.ID][index[winner.ID]]++
+						won[winner.ID] = true
+					}
+				}
+				for id := range won {
+					anyWins[index[id]]++
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	result := SimulationResult{Runs: opts.Runs, EventYear: eventYear, Prizes: prizes, Participants: make([]ParticipantOdds, len(participants))}
+	buckets := make([]*BucketOdds, MaxHistoryBucket+1)
+	for i, p := range participants {
+		odds := ParticipantOdds{Participant: p, Bucket: HistoryBucket(p, eventYear), Wins: make(map[int]int, len(prizes))}
+		for w := range workers {
+			for _, prize := range prizes {
+				odds.Wins[prize.ID] += totals[w][prize.ID][i]
+			}
+			odds.AnyWins += anyTotals[w][i]
+		}
+		result.Participants[i] = odds
+
+		b := buckets[odds.Bucket]
+		if b == nil {
+			b = &BucketOdds{Bucket: odds.Bucket, Wins: make(map[int]int, len(prizes))}
+			buckets[odds.Bucket] = b
+		}
+		b.Participants++
+		b.AnyWins += odds.AnyWins
+		for id, n := range odds.Wins {
+			b.Wins[id] += n
+		}
+	}
+	for _, b := range buckets {
+		if b != nil {
+			result.Buckets = append(result.Buckets, *b)
+		}
+	}
+	return result, nil
+}
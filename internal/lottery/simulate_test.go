@@ -0,0 +1,88 @@
+package lottery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+func TestHistoryBucket(t *testing.T) {
+	testCases := []struct {
+		name     string
+		years    []int
+		expected int
+	}{
+		{name: "从未中奖", expected: 0},
+		{name: "去年中奖", years: []int{2024}, expected: 1},
+		{name: "取最近一次中奖", years: []int{2020, 2023}, expected: 2},
+		{name: "更早的中奖合并为一组", years: []int{2010}, expected: MaxHistoryBucket},
+		{name: "今年已中奖算作一年内", years: []int{2025}, expected: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p model.Participant
+			for _, y := range tc.years {
+				p.WinningHistory = append(p.WinningHistory, model.WinningRecord{Year: y, PrizeLevel: 1})
+			}
+			assert.Equal(t, tc.expected, HistoryBucket(p, 2025))
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	// 前 10 人去年中过一等奖，默认的历史衰减策略应显著降低他们的中奖概率
+	participants := createTestParticipants(40)
+	for i := range 10 {
+		participants[i].WinningHistory = []model.WinningRecord{{Year: 2024, PrizeLevel: 1}}
+	}
+	prizes := createTestPrizes()
+	for i := range prizes {
+		prizes[i].Probability = 1 // 概率为 0 时所有人权重相同
+	}
+
+	result, err := Simulate(participants, prizes, SimulateOptions{Runs: 300, Seed: 1, EventYear: 2025})
+	require.NoError(t, err)
+	require.Len(t, result.Participants, 40)
+
+	// 每场都会抽满所有名额，各奖项的概率之和等于名额数
+	for _, prize := range prizes {
+		total := 0.0
+		for _, o := range result.Participants {
+			total += result.Probability(o, prize.ID)
+		}
+		assert.InDelta(t, float64(prize.Count), total, 1e-9, "%s 的概率之和", prize.Name)
+	}
+
+	require.Len(t, result.Buckets, 2)
+	never, recent := result.Buckets[0], result.Buckets[1]
+	assert.Equal(t, 0, never.Bucket)
+	assert.Equal(t, 30, never.Participants)
+	assert.Equal(t, 1, recent.Bucket)
+	assert.Equal(t, 10, recent.Participants)
+	assert.Greater(t, result.BucketAnyProbability(never), result.BucketAnyProbability(recent), "去年中过奖的人中奖概率应更低")
+
+	// 相同的参数得到相同的结果
+	again, err := Simulate(participants, prizes, SimulateOptions{Runs: 300, Seed: 1, EventYear: 2025})
+	require.NoError(t, err)
+	assert.Equal(t, result, again)
+}
+
+func TestSimulate_Clock(t *testing.T) {
+	jan2 := func() time.Time { return time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local) }
+	result, err := Simulate(createTestParticipants(20), createTestPrizes(), SimulateOptions{Runs: 1, Clock: jan2})
+	require.NoError(t, err)
+	assert.Equal(t, 2026, result.EventYear, "未设置活动年份时使用时钟的年份")
+}
+
+func TestSimulate_Errors(t *testing.T) {
+	_, err := Simulate(createTestParticipants(5), createTestPrizes(), SimulateOptions{})
+	assert.Error(t, err, "模拟场次必须大于 0")
+
+	prizes := []model.Prize{{ID: 1, Name: "购物卡", Count: 1, Quotas: []string{"office <= 1"}}}
+	_, err = Simulate(createTestParticipants(5), prizes, SimulateOptions{Runs: 1})
+	assert.Error(t, err, "配置错误的奖项应在模拟前报错")
+}
@@ -0,0 +1,299 @@
+package lottery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/palemoky/lucky-day/internal/lottery/fairness"
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// 引擎操作类型
+const (
+	OpDraw    = "draw"    // 抽奖
+	OpReset   = "reset"   // 重置奖项
+	OpForfeit = "forfeit" // 弃奖并补抽
+	OpUndo    = "undo"    // 撤销上一次操作
+	OpRedo    = "redo"    // 重做上一次撤销的操作
+)
+
+// Event 引擎操作记录，按发生顺序追加，用于赛后复核
+type Event struct {
+	Seq           int    `json:"seq"` // 操作序号，同时决定该次抽奖派生的随机数
+	Op            string `json:"op"`
+	PrizeID       int    `json:"prize_id"`
+	Count         int    `json:"count,omitempty"`          // 抽奖时本批抽取的人数，为 0 时表示抽完剩余名额
+	WinnerIDs     []int  `json:"winner_ids,omitempty"`     // 按抽中顺序排列，弃奖时为补抽的中奖者
+	ParticipantID int    `json:"participant_id,omitempty"` // 弃奖者
+	Exclude       bool   `json:"exclude,omitempty"`        // 弃奖者是否被取消后续抽奖资格
+}
+
+// record 追加一条操作记录，并写入会话日志
+func (e *Engine) record(ev Event) {
+	ev.Seq = len(e.events)
+	e.events = append(e.events, ev)
+	if e.journal != nil && e.journalErr == nil {
+		e.journalErr = e.journal.append(ev)
+	}
+}
+
+// Events 返回到目前为止的所有操作记录
+func (e *Engine) Events() []Event {
+	return slices.Clone(e.events)
+}
+
+// Commit 生成抽奖前需要公布的承诺值，必须在第一次抽奖前调用。
+// 活动年份在此固定，名单中本届及以后的中奖记录随之去掉，见 PriorHistory
+func (e *Engine) Commit() (string, error) {
+	salt, err := fairness.NewSalt()
+	if err != nil {
+		return "", err
+	}
+	e.salt = salt
+	e.eventYear = e.EventYear() // 固定活动年份，跨年的抽奖也使用同一年份
+	e.allParticipants = PriorHistory(e.allParticipants, e.eventYear)
+	for _, p := range e.allParticipants {
+		if _, ok := e.eligible[p.ID]; ok {
+			e.eligible[p.ID] = p
+		}
+	}
+	e.poolVersion++ // 参与条件可能依赖中奖历史，缓存的预览人数作废
+	e.commitment = commitFor(e.seed, salt, e.allParticipants, e.prizes, e.weights, e.eventYear)
+	return e.commitment, nil
+}
+
+// commitFor 计算承诺值，覆盖名单、奖品配置、权重策略和活动年份
+func commitFor(seed int64, salt string, participants []model.Participant, prizes []model.Prize, weights WeightStrategy, eventYear int) string {
+	return fairness.Commit(seed, salt,
+		fairness.RosterDigest(participants),
+		fairness.PrizesDigest(prizes),
+		fairness.ConfigDigest(fmt.Sprintf("%s;event_year=%d", weights.String(), eventYear)))
+}
+
+// Commitment 返回已生成的承诺值，未调用 Commit 时为空
+func (e *Engine) Commitment() string {
+	return e.commitment
+}
+
+// Reveal 赛后公开的验证材料
+type Reveal struct {
+	Commitment string  `json:"commitment"`
+	Seed       int64   `json:"seed"`
+	Salt       string  `json:"salt"`
+	Weighting  string  `json:"weighting"`  // 权重策略描述，便于核对配置
+	EventYear  int     `json:"event_year"` // 计算权重使用的活动年份
+	Events     []Event `json:"events"`
+}
+
+// Reveal 返回可公开的种子、盐及全部操作记录
+func (e *Engine) Reveal() Reveal {
+	return Reveal{
+		Commitment: e.commitment,
+		Seed:       e.seed,
+		Salt:       e.salt,
+		Weighting:  e.weights.String(),
+		EventYear:  e.EventYear(),
+		Events:     e.Events(),
+	}
+}
+
+// WriteReveal 将验证材料写入 JSON 文件
+func WriteReveal(path string, r Reveal) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("编码验证材料失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("写入验证材料失败: %w", err)
+	}
+	return nil
+}
+
+// LoadReveal 从 JSON 文件读取验证材料
+func LoadReveal(path string) (Reveal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Reveal{}, fmt.Errorf("读取验证材料失败: %w", err)
+	}
+	var r Reveal
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Reveal{}, fmt.Errorf("解析验证材料失败: %w", err)
+	}
+	return r, nil
+}
+
+// PrizeVerification 单个奖项的验证结果
+type PrizeVerification struct {
+	PrizeID   int
+	PrizeName string
+	Draws     int // 该奖项被重放的抽奖次数
+	Passed    bool
+	Reason    string // 未通过时的原因
+}
+
+// VerifyReport 验证报告
+type VerifyReport struct {
+	CommitmentOK bool   // 揭示的种子、名单和奖品能否还原出公布的承诺
+	Commitment   string // 由揭示材料重新计算出的承诺
+	Prizes       []PrizeVerification
+	Errors       []string // 无法归属到具体奖项的问题
+}
+
+// Passed 承诺一致且所有奖项均通过时返回 true
+func (r VerifyReport) Passed() bool {
+	if !r.CommitmentOK || len(r.Errors) > 0 {
+		return false
+	}
+	for _, p := range r.Prizes {
+		if !p.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify 使用揭示的种子离线重放所有操作，逐个奖项核对中奖结果。
+// published 是抽奖前公布的承诺值；participants、prizes 和 weights 是公开的名单、奖品配置和权重策略，
+// weights 为 nil 时使用默认策略。名单中本届及以后的中奖记录会被忽略，见 PriorHistory。
+func Verify(participants []model.Participant, prizes []model.Prize, weights WeightStrategy, reveal Reveal, published string) VerifyReport {
+	engine := NewEngine(PriorHistory(participants, reveal.EventYear), prizes, reveal.Seed)
+	engine.SetWeightStrategy(weights)
+	engine.SetEventYear(reveal.EventYear)
+
+	report := VerifyReport{
+		Commitment: commitFor(reveal.Seed, reveal.Salt, engine.allParticipants, prizes, engine.weights, reveal.EventYear),
+	}
+	report.CommitmentOK = report.Commitment == published
+
+	results := make(map[int]*PrizeVerification, len(prizes))
+	for _, p := range engine.prizes {
+		results[p.ID] = &PrizeVerification{PrizeID: p.ID, PrizeName: p.Name, Passed: true}
+	}
+	fail := func(prizeID int, reason string) {
+		res, ok := results[prizeID]
+		if !ok {
+			report.Errors = append(report.Errors, reason)
+			return
+		}
+		if res.Passed {
+			res.Passed = false
+			res.Reason = reason
+		}
+	}
+
+	for i, ev := range reveal.Events {
+		if ev.Seq != i {
+			fail(ev.PrizeID, fmt.Sprintf("操作序号不连续: 期望 %d，实际 %d", i, ev.Seq))
+		}
+		switch ev.Op {
+		case OpDraw:
+			engine.verifyDraw(ev, results, fail)
+		case OpReset:
+			engine.ResetPrize(ev.PrizeID)
+		case OpForfeit:
+			engine.verifyForfeit(ev, fail)
+		case OpUndo, OpRedo:
+			replay := engine.Undo
+			if ev.Op == OpRedo {
+				replay = engine.Redo
+			}
+			if _, err := replay(); err != nil {
+				fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作无法重放: %v", ev.Seq, err))
+				engine.record(ev)
+			}
+		default:
+			fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作类型未知: %q", i, ev.Op))
+			engine.record(ev)
+		}
+	}
+
+	for _, p := range engine.prizes {
+		report.Prizes = append(report.Prizes, *results[p.ID])
+	}
+	return report
+}
+
+// verifyDraw 重放一次抽奖并与揭示材料中的中奖者比较。
+// 无论比较结果如何，都按揭示材料中的中奖者推进状态，使后续奖项可以被独立核对。
+func (e *Engine) verifyDraw(ev Event, results map[int]*PrizeVerification, fail func(int, string)) {
+	prizeIndex := e.prizeIndex(ev.PrizeID)
+	if prizeIndex < 0 {
+		fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作引用了不存在的奖项 %d", ev.Seq, ev.PrizeID))
+		e.record(ev)
+		return
+	}
+	results[ev.PrizeID].Draws++
+
+	prize := e.prizes[prizeIndex]
+	count := prize.Count - prize.DrawnCount
+	if ev.Count > 0 {
+		count = min(ev.Count, count)
+	}
+	expected, err := e.pick(prize, count, e.drawRand(len(e.events), ev.PrizeID))
+	if err != nil {
+		fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作无法重放: %v", ev.Seq, err))
+	} else if !slices.Equal(participantIDs(expected), ev.WinnerIDs) {
+		fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作的中奖者不一致: 重放结果 %v，公布结果 %v",
+			ev.Seq, participantIDs(expected), ev.WinnerIDs))
+	}
+
+	claimed := make([]model.Participant, 0, len(ev.WinnerIDs))
+	seen := make(map[int]bool, len(ev.WinnerIDs))
+	for _, id := range ev.WinnerIDs {
+		p, ok := e.eligible[id]
+		if !ok || seen[id] {
+			fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作的中奖者 %d 不在候选池中", ev.Seq, id))
+			claimed = expected
+			break
+		}
+		seen[id] = true
+		claimed = append(claimed, p)
+	}
+	e.do(Event{Op: OpDraw, PrizeID: ev.PrizeID, Count: ev.Count, WinnerIDs: participantIDs(claimed)},
+		func() { e.applyDraw(prizeIndex, claimed) },
+		func() { e.undoDraw(prizeIndex, claimed) })
+}
+
+// verifyForfeit 重放一次弃奖补抽并与揭示材料中的补抽结果比较，同样按公布结果推进状态
+func (e *Engine) verifyForfeit(ev Event, fail func(int, string)) {
+	prizeIndex := e.prizeIndex(ev.PrizeID)
+	isWinner := prizeIndex >= 0 && slices.ContainsFunc(e.allWinners[ev.PrizeID], func(p model.Participant) bool {
+		return p.ID == ev.ParticipantID
+	})
+	if !isWinner || len(ev.WinnerIDs) != 1 {
+		fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作的弃奖记录无效: 弃奖者 %d，补抽 %v", ev.Seq, ev.ParticipantID, ev.WinnerIDs))
+		e.record(ev)
+		return
+	}
+
+	expected, err := e.pickReplacement(e.prizes[prizeIndex], ev.ParticipantID, e.drawRand(len(e.events), ev.PrizeID))
+	if err != nil {
+		fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作无法重放: %v", ev.Seq, err))
+	} else if expected.ID != ev.WinnerIDs[0] {
+		fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作的补抽结果不一致: 重放结果 %d，公布结果 %d", ev.Seq, expected.ID, ev.WinnerIDs[0]))
+	}
+
+	claimed, ok := e.eligible[ev.WinnerIDs[0]]
+	if !ok {
+		fail(ev.PrizeID, fmt.Sprintf("第 %d 条操作的补抽者 %d 不在候选池中", ev.Seq, ev.WinnerIDs[0]))
+		if err != nil {
+			e.record(ev)
+			return
+		}
+		claimed = expected
+	}
+	e.do(Event{Op: OpForfeit, PrizeID: ev.PrizeID, ParticipantID: ev.ParticipantID, Exclude: ev.Exclude, WinnerIDs: []int{claimed.ID}},
+		func() { e.applyForfeit(ev.PrizeID, ev.ParticipantID, claimed, ev.Exclude) },
+		func() { e.undoForfeit(ev.PrizeID) })
+}
+
+// participantIDs 按顺序提取参与者 ID
+func participantIDs(participants []model.Participant) []int {
+	ids := make([]int, len(participants))
+	for i, p := range participants {
+		ids[i] = p.ID
+	}
+	return ids
+}
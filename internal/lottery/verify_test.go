@@ -0,0 +1,164 @@
+package lottery
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// runCommittedDraw 模拟一次完整的活动：公布承诺、抽取所有奖项并重置其中一个后重抽
+func runCommittedDraw(t *testing.T, participants []model.Participant, prizes []model.Prize) (string, Reveal) {
+	t.Helper()
+	engine := NewEngine(participants, prizes, 20250101)
+	commitment, err := engine.Commit()
+	require.NoError(t, err)
+
+	for _, prize := range prizes {
+		_, err := engine.Draw(prize.ID)
+		require.NoError(t, err)
+	}
+	engine.ResetPrize(prizes[1].ID)
+	_, err = engine.Draw(prizes[1].ID)
+	require.NoError(t, err)
+
+	return commitment, engine.Reveal()
+}
+
+func TestVerify(t *testing.T) {
+	participants := createTestParticipants(30)
+	prizes := createTestPrizes()
+	commitment, reveal := runCommittedDraw(t, participants, prizes)
+
+	t.Run("如实公布的结果通过验证", func(t *testing.T) {
+		report := Verify(participants, prizes, nil, reveal, commitment)
+		assert.True(t, report.CommitmentOK)
+		assert.True(t, report.Passed())
+		require.Len(t, report.Prizes, len(prizes))
+		for _, p := range report.Prizes {
+			assert.True(t, p.Passed, p.Reason)
+		}
+		assert.Equal(t, 2, report.Prizes[1].Draws, "重置后重抽的奖项应被重放两次")
+	})
+
+	t.Run("篡改种子", func(t *testing.T) {
+		tampered := reveal
+		tampered.Seed++
+		report := Verify(participants, prizes, nil, tampered, commitment)
+		assert.False(t, report.CommitmentOK)
+		assert.False(t, report.Passed())
+	})
+
+	t.Run("篡改盐", func(t *testing.T) {
+		tampered := reveal
+		tampered.Salt = "00" + tampered.Salt[2:]
+		report := Verify(participants, prizes, nil, tampered, commitment)
+		assert.False(t, report.CommitmentOK)
+	})
+
+	t.Run("篡改名单", func(t *testing.T) {
+		tampered := createTestParticipants(30)
+		tampered[5].Name = "冒名顶替"
+		report := Verify(tampered, prizes, nil, reveal, commitment)
+		assert.False(t, report.CommitmentOK)
+		assert.False(t, report.Passed())
+	})
+
+	t.Run("篡改奖品配置", func(t *testing.T) {
+		tampered := createTestPrizes()
+		tampered[2].Count = 9
+		report := Verify(participants, tampered, nil, reveal, commitment)
+		assert.False(t, report.CommitmentOK)
+		assert.False(t, report.Passed())
+	})
+
+	t.Run("篡改权重策略", func(t *testing.T) {
+		report := Verify(participants, prizes, UniformWeight{}, reveal, commitment)
+		assert.False(t, report.CommitmentOK)
+		assert.False(t, report.Passed())
+	})
+
+	t.Run("篡改活动年份", func(t *testing.T) {
+		tampered := reveal
+		tampered.EventYear--
+		report := Verify(participants, prizes, nil, tampered, commitment)
+		assert.False(t, report.CommitmentOK)
+	})
+
+	t.Run("篡改中奖者", func(t *testing.T) {
+		tampered := reveal
+		tampered.Events = engineEventsCopy(reveal.Events)
+		// 将一等奖的中奖者替换为另一个未中奖的人
+		tampered.Events[0].WinnerIDs = []int{findLoser(reveal.Events, 30)}
+
+		report := Verify(participants, prizes, nil, tampered, commitment)
+		assert.True(t, report.CommitmentOK)
+		assert.False(t, report.Passed())
+		assert.False(t, report.Prizes[0].Passed)
+		assert.NotEmpty(t, report.Prizes[0].Reason)
+	})
+
+	t.Run("删除操作记录", func(t *testing.T) {
+		tampered := reveal
+		tampered.Events = engineEventsCopy(reveal.Events[1:])
+		report := Verify(participants, prizes, nil, tampered, commitment)
+		assert.False(t, report.Passed())
+	})
+}
+
+func TestVerify_DuplicateIDs(t *testing.T) {
+	participants := append(createTestParticipants(30), model.Participant{ID: 3, Name: "重复登记"})
+	prizes := createTestPrizes()
+	commitment, reveal := runCommittedDraw(t, participants, prizes)
+
+	// 复核读取的是同一份包含重复 ID 的名单，去重规则相同，摘要一致
+	report := Verify(participants, prizes, nil, reveal, commitment)
+	assert.True(t, report.CommitmentOK)
+	assert.True(t, report.Passed())
+}
+
+func TestRevealFile(t *testing.T) {
+	participants := createTestParticipants(10)
+	prizes := createTestPrizes()
+	commitment, reveal := runCommittedDraw(t, participants, prizes)
+
+	path := filepath.Join(t.TempDir(), "reveal.json")
+	require.NoError(t, WriteReveal(path, reveal))
+
+	loaded, err := LoadReveal(path)
+	require.NoError(t, err)
+	assert.Equal(t, reveal, loaded)
+	assert.Equal(t, commitment, loaded.Commitment)
+
+	_, err = LoadReveal(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+// engineEventsCopy 深拷贝操作记录，避免篡改影响原始数据
+func engineEventsCopy(events []Event) []Event {
+	copied := make([]Event, len(events))
+	for i, ev := range events {
+		copied[i] = ev
+		copied[i].WinnerIDs = append([]int(nil), ev.WinnerIDs...)
+	}
+	return copied
+}
+
+// findLoser 返回一个从未中奖的参与者 ID
+func findLoser(events []Event, participants int) int {
+	won := make(map[int]bool)
+	for _, ev := range events {
+		for _, id := range ev.WinnerIDs {
+			won[id] = true
+		}
+	}
+	for id := 1; id <= participants; id++ {
+		if !won[id] {
+			return id
+		}
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package lottery
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// 内置权重策略名称，对应 config.yml 中 weighting 的 strategy 字段
+const (
+	StrategyUniform         = "uniform"
+	StrategyHistoryDecay    = "history_decay"
+	StrategyTenureBonus     = "tenure_bonus"
+	StrategyAttendanceBonus = "attendance_bonus"
+)
+
+// 历史衰减策略的默认参数
+const (
+	defaultDecayFactor        = 0.5  // 衰减因子
+	defaultLevelPenaltyFactor = 1.5  // 等级惩罚因子
+	defaultMinWeight          = 0.01 // 最低权重
+)
+
+// WeightStrategy 计算参与者抽取某个奖项的基础权重，最终权重还会乘以奖品的中奖概率。
+// String 返回策略及其参数的规范描述，会被纳入抽奖承诺，修改策略即可被验证发现。
+type WeightStrategy interface {
+	Weight(participant model.Participant, prize model.Prize, eventYear int) float64
+	String() string
+}
+
+// UniformWeight 所有人权重相同
+type UniformWeight struct{}
+
+func (UniformWeight) Weight(model.Participant, model.Prize, int) float64 { return 1 }
+
+func (UniformWeight) String() string { return StrategyUniform }
+
+// HistoryDecayWeight 根据往年中奖记录降低权重：年份越近、奖项等级越高，惩罚越重
+type HistoryDecayWeight struct {
+	DecayFactor        float64
+	LevelPenaltyFactor float64
+	MinWeight          float64
+}
+
+func (w HistoryDecayWeight) Weight(participant model.Participant, _ model.Prize, eventYear int) float64 {
+	baseWeight := 1.0
+	for _, record := range participant.WinningHistory {
+		yearDiff := float64(eventYear - record.Year)
+		timePenalty := math.Exp(-w.DecayFactor * yearDiff)                           // 时间惩罚
+		levelPenalty := math.Pow(w.LevelPenaltyFactor, float64(5-record.PrizeLevel)) // 等级惩罚
+		baseWeight -= timePenalty * levelPenalty
+	}
+
+	if baseWeight < w.MinWeight {
+		return w.MinWeight // 保证最低权重
+	}
+	return baseWeight
+}
+
+func (w HistoryDecayWeight) String() string {
+	return fmt.Sprintf("%s(decay_factor=%g,level_penalty_factor=%g,min_weight=%g)",
+		StrategyHistoryDecay, w.DecayFactor, w.LevelPenaltyFactor, w.MinWeight)
+}
+
+// TenureBonusWeight 司龄越长权重越高：1 + min(司龄 × 每年加成, 加成上限)
+type TenureBonusWeight struct {
+	BonusPerYear float64
+	MaxBonus     float64
+}
+
+func (w TenureBonusWeight) Weight(participant model.Participant, _ model.Prize, _ int) float64 {
+	return 1 + math.Min(math.Max(participant.Tenure, 0)*w.BonusPerYear, w.MaxBonus)
+}
+
+func (w TenureBonusWeight) String() string {
+	return fmt.Sprintf("%s(bonus_per_year=%g,max_bonus=%g)", StrategyTenureBonus, w.BonusPerYear, w.MaxBonus)
+}
+
+// AttendanceBonusWeight 参加往届活动次数越多权重越高：1 + min(参加次数 × 每次加成, 加成上限)
+type AttendanceBonusWeight struct {
+	BonusPerEvent float64
+	MaxBonus      float64
+}
+
+func (w AttendanceBonusWeight) Weight(participant model.Participant, _ model.Prize, _ int) float64 {
+	return 1 + math.Min(float64(max(participant.Attendance, 0))*w.BonusPerEvent, w.MaxBonus)
+}
+
+func (w AttendanceBonusWeight) String() string {
+	return fmt.Sprintf("%s(bonus_per_event=%g,max_bonus=%g)", StrategyAttendanceBonus, w.BonusPerEvent, w.MaxBonus)
+}
+
+// CombinedWeight 将多个策略的权重相乘，例如历史衰减叠加司龄加成
+type CombinedWeight []WeightStrategy
+
+func (c CombinedWeight) Weight(participant model.Participant, prize model.Prize, eventYear int) float64 {
+	weight := 1.0
+	for _, s := range c {
+		weight *= s.Weight(participant, prize, eventYear)
+	}
+	return weight
+}
+
+func (c CombinedWeight) String() string {
+	names := make([]string, len(c))
+	for i, s := range c {
+		names[i] = s.String()
+	}
+	return strings.Join(names, "*")
+}
+
+// DefaultWeightStrategy 返回默认的历史衰减策略
+func DefaultWeightStrategy() WeightStrategy {
+	return HistoryDecayWeight{
+		DecayFactor:        defaultDecayFactor,
+		LevelPenaltyFactor: defaultLevelPenaltyFactor,
+		MinWeight:          defaultMinWeight,
+	}
+}
+
+// NewWeightStrategy 按名称和参数创建内置权重策略，未提供的参数使用默认值
+func NewWeightStrategy(name string, params map[string]float64) (WeightStrategy, error) {
+	var (
+		strategy WeightStrategy
+		known    []string
+	)
+	switch name {
+	case StrategyUniform:
+		strategy = UniformWeight{}
+	case StrategyHistoryDecay, "":
+		known = []string{"decay_factor", "level_penalty_factor", "min_weight"}
+		strategy = HistoryDecayWeight{
+			DecayFactor:        param(params, "decay_factor", defaultDecayFactor),
+			LevelPenaltyFactor: param(params, "level_penalty_factor", defaultLevelPenaltyFactor),
+			MinWeight:          param(params, "min_weight", defaultMinWeight),
+		}
+	case StrategyTenureBonus:
+		known = []string{"bonus_per_year", "max_bonus"}
+		strategy = TenureBonusWeight{
+			BonusPerYear: param(params, "bonus_per_year", 0.1),
+			MaxBonus:     param(params, "max_bonus", 1.0),
+		}
+	case StrategyAttendanceBonus:
+		known = []string{"bonus_per_event", "max_bonus"}
+		strategy = AttendanceBonusWeight{
+			BonusPerEvent: param(params, "bonus_per_event", 0.1),
+			MaxBonus:      param(params, "max_bonus", 1.0),
+		}
+	default:
+		return nil, fmt.Errorf("未知的权重策略: %s", name)
+	}
+
+	// 拼错的参数名会被静默忽略，这里显式报错
+	for _, k := range slices.Sorted(maps.Keys(params)) {
+		if !slices.Contains(known, k) {
+			return nil, fmt.Errorf("权重策略 %s 不支持参数 %s", name, k)
+		}
+		if params[k] < 0 {
+			return nil, fmt.Errorf("权重策略 %s 的参数 %s 不能为负数", name, k)
+		}
+	}
+
+	return strategy, nil
+}
+
+// param 读取策略参数，不存在时返回默认值
+func param(params map[string]float64, key string, def float64) float64 {
+	if v, ok := params[key]; ok {
+		return v
+	}
+	return def
+}
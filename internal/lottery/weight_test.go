@@ -0,0 +1,204 @@
+package lottery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+func TestNewWeightStrategy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy string
+		params   map[string]float64
+		expected WeightStrategy
+		wantErr  bool
+	}{
+		{
+			name:     "均匀策略",
+			strategy: StrategyUniform,
+			expected: UniformWeight{},
+		},
+		{
+			name:     "未指定策略时使用默认历史衰减",
+			strategy: "",
+			expected: DefaultWeightStrategy(),
+		},
+		{
+			name:     "历史衰减策略覆盖部分参数",
+			strategy: StrategyHistoryDecay,
+			params:   map[string]float64{"decay_factor": 1.0},
+			expected: HistoryDecayWeight{DecayFactor: 1.0, LevelPenaltyFactor: 1.5, MinWeight: 0.01},
+		},
+		{
+			name:     "司龄加成策略",
+			strategy: StrategyTenureBonus,
+			params:   map[string]float64{"bonus_per_year": 0.2, "max_bonus": 2},
+			expected: TenureBonusWeight{BonusPerYear: 0.2, MaxBonus: 2},
+		},
+		{
+			name:     "出勤加成策略使用默认参数",
+			strategy: StrategyAttendanceBonus,
+			expected: AttendanceBonusWeight{BonusPerEvent: 0.1, MaxBonus: 1},
+		},
+		{
+			name:     "未知策略",
+			strategy: "lucky_star",
+			wantErr:  true,
+		},
+		{
+			name:     "拼错的参数名",
+			strategy: StrategyTenureBonus,
+			params:   map[string]float64{"bonus_per_yaer": 0.2},
+			wantErr:  true,
+		},
+		{
+			name:     "负数参数",
+			strategy: StrategyHistoryDecay,
+			params:   map[string]float64{"decay_factor": -1},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy, err := NewWeightStrategy(tc.strategy, tc.params)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, strategy)
+		})
+	}
+}
+
+func TestWeightStrategies(t *testing.T) {
+	const delta = 0.001
+	veteran := model.Participant{ID: 1, Tenure: 8, Attendance: 3}
+	newcomer := model.Participant{ID: 2, Tenure: 0.5}
+	lastYearWinner := model.Participant{ID: 3, WinningHistory: []model.WinningRecord{{Year: 2024, PrizeLevel: 3}}}
+
+	testCases := []struct {
+		name        string
+		strategy    WeightStrategy
+		participant model.Participant
+		expected    float64
+	}{
+		{name: "均匀策略忽略中奖历史", strategy: UniformWeight{}, participant: lastYearWinner, expected: 1},
+		{name: "历史衰减与 calculateWeight 一致", strategy: DefaultWeightStrategy(), participant: lastYearWinner, expected: 0.01},
+		{name: "司龄加成", strategy: TenureBonusWeight{BonusPerYear: 0.1, MaxBonus: 1}, participant: newcomer, expected: 1.05},
+		{name: "司龄加成封顶", strategy: TenureBonusWeight{BonusPerYear: 0.2, MaxBonus: 1}, participant: veteran, expected: 2},
+		{name: "出勤加成", strategy: AttendanceBonusWeight{BonusPerEvent: 0.1, MaxBonus: 1}, participant: veteran, expected: 1.3},
+		{
+			name:        "组合策略权重相乘",
+			strategy:    CombinedWeight{TenureBonusWeight{BonusPerYear: 0.1, MaxBonus: 1}, AttendanceBonusWeight{BonusPerEvent: 0.1, MaxBonus: 1}},
+			participant: veteran,
+			expected:    1.8 * 1.3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			weight := tc.strategy.Weight(tc.participant, model.Prize{}, 2025)
+			assert.InDelta(t, tc.expected, weight, delta)
+		})
+	}
+}
+
+func TestEngine_EventYear(t *testing.T) {
+	// 2026 年 1 月 2 日举办 2025 财年的年会，去年中奖指的是 2024 年
+	jan2 := func() time.Time { return time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local) }
+	winner := model.Participant{ID: 1, Name: "去年中奖", WinningHistory: []model.WinningRecord{{Year: 2024, PrizeLevel: 5}}}
+	prize := model.Prize{ID: 1, Name: "一等奖", Count: 1, Probability: 1}
+
+	weightIn := func(engine *Engine) float64 {
+		choices, err := engine.getWeightedChoices(prize)
+		require.NoError(t, err)
+		return choices[0].Weight
+	}
+
+	engine := NewEngine([]model.Participant{winner}, []model.Prize{prize}, 1)
+	engine.SetClock(jan2)
+	assert.Equal(t, 2026, engine.EventYear(), "未设置活动年份时使用时钟的年份")
+	assert.InDelta(t, calculateWeight(winner, 2026), weightIn(engine), 1e-9)
+
+	engine.SetEventYear(2025)
+	assert.Equal(t, 2025, engine.EventYear())
+	assert.InDelta(t, calculateWeight(winner, 2025), weightIn(engine), 1e-9)
+	assert.NotEqual(t, calculateWeight(winner, 2025), calculateWeight(winner, 2026))
+
+	// 公布承诺后活动年份固定，跨年也不会改变
+	engine = NewEngine([]model.Participant{winner}, []model.Prize{prize}, 1)
+	engine.SetClock(jan2)
+	_, err := engine.Commit()
+	require.NoError(t, err)
+	engine.SetClock(func() time.Time { return time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local) })
+	assert.Equal(t, 2026, engine.EventYear())
+	assert.Equal(t, 2026, engine.Reveal().EventYear)
+}
+
+func TestEngine_CommitUsesClockYear(t *testing.T) {
+	// 未设置活动年份时，由引擎时钟决定哪些中奖记录属于本届
+	jan2 := func() time.Time { return time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local) }
+	winner := model.Participant{ID: 1, Name: "本届已中奖", WinningHistory: []model.WinningRecord{{Year: 2024, PrizeLevel: 5}, {Year: 2026, PrizeLevel: 1}}}
+	prize := model.Prize{ID: 1, Name: "一等奖", Count: 1, Probability: 1}
+
+	engine := NewEngine([]model.Participant{winner}, []model.Prize{prize}, 1)
+	engine.SetClock(jan2)
+	commitment, err := engine.Commit()
+	require.NoError(t, err)
+
+	choices, err := engine.getWeightedChoices(prize)
+	require.NoError(t, err)
+	prior := PriorHistory([]model.Participant{winner}, 2026)[0]
+	assert.InDelta(t, calculateWeight(prior, 2026), choices[0].Weight, 1e-9, "本届保存的中奖记录不降低权重")
+
+	// 复核读取的名单包含本届的中奖记录，摘要仍与承诺一致
+	_, err = engine.Draw(prize.ID)
+	require.NoError(t, err)
+	report := Verify([]model.Participant{winner}, []model.Prize{prize}, nil, engine.Reveal(), commitment)
+	assert.True(t, report.Passed(), "%+v", report)
+}
+
+func TestEngine_SetWeightStrategy(t *testing.T) {
+	// 两人中只有一人有司龄，零加成时司龄为 0 的人权重为 1，司龄 10 年的人权重为 11
+	participants := []model.Participant{{ID: 1, Name: "老员工", Tenure: 10}, {ID: 2, Name: "新员工"}}
+	prizes := []model.Prize{{ID: 1, Name: "一等奖", Count: 1, Probability: 1}}
+
+	wins := 0
+	for seed := range int64(200) {
+		engine := NewEngine(participants, prizes, seed)
+		engine.SetWeightStrategy(TenureBonusWeight{BonusPerYear: 1, MaxBonus: 10})
+		winners, err := engine.Draw(1)
+		require.NoError(t, err)
+		if winners[0].ID == 1 {
+			wins++
+		}
+	}
+	assert.Greater(t, wins, 160, "司龄加成后老员工应远比新员工更容易中奖")
+
+	engine := NewEngine(participants, prizes, 1)
+	engine.SetWeightStrategy(nil)
+	assert.Equal(t, DefaultWeightStrategy(), engine.WeightStrategy(), "nil 应回退到默认策略")
+}
+
+func TestEngine_ParticipantWeight(t *testing.T) {
+	participants := []model.Participant{
+		{ID: 1, Name: "未指定"},
+		{ID: 2, Name: "双倍", Weight: 2},
+	}
+	prize := model.Prize{ID: 1, Name: "一等奖", Count: 1, Probability: 1}
+	engine := NewEngine(participants, []model.Prize{prize}, 1)
+	engine.SetWeightStrategy(UniformWeight{})
+
+	choices, err := engine.getWeightedChoices(prize)
+	require.NoError(t, err)
+	require.Len(t, choices, 2)
+	assert.InDelta(t, 1.0, choices[0].Weight, 1e-9, "未指定权重系数时按 1 计算")
+	assert.InDelta(t, 2.0, choices[1].Weight, 1e-9, "名单中的权重系数与策略结果相乘")
+}
@@ -1,5 +1,7 @@
 package model
 
+import "strings"
+
 // PrizeLevel 定义奖品等级类型
 type PrizeLevel int
 
@@ -36,16 +38,38 @@ type Prize struct {
 	ID          int
 	Name        string
 	Level       PrizeLevel
-	Count       int     // 奖品数量
-	Probability float64 // 中奖概率
-	DrawnCount  int     // 已抽奖数量
+	Count       int      // 奖品数量
+	Probability float64  // 中奖概率
+	DrawnCount  int      // 已抽奖数量
+	Quotas      []string // 分组配额，如 "department <= 2"、"department >= 1"
+	Eligibility []string // 参与条件，如 "id != 1,2"、"department != 总裁办"、"tenure >= 1"
+	BatchSize   int      `mapstructure:"batch_size"` // 每次抽取的人数，0 表示一次抽完剩余名额
 }
 
 // Participant 参与者结构体
 type Participant struct {
 	ID             int `gorm:"primaryKey"`
 	Name           string
-	WinningHistory []WinningRecord `gorm:"foreignKey:ParticipantID"`
+	Department     string            // 部门
+	Email          string            // 邮箱
+	Tenure         float64           // 司龄（年）
+	Attendance     int               // 参加往届活动的次数
+	Weight         float64           // 名单中指定的权重系数，与权重策略的结果相乘；0 表示未指定
+	Attributes     map[string]string `gorm:"serializer:json" json:",omitempty"` // 名单中其他列的取值，键为表头；为空时不计入名单摘要
+	WinningHistory []WinningRecord   `gorm:"foreignKey:ParticipantID"`
+}
+
+// Attribute 返回名为 name 的扩展属性，名称不区分大小写
+func (p Participant) Attribute(name string) (string, bool) {
+	if v, ok := p.Attributes[name]; ok {
+		return v, true
+	}
+	for k, v := range p.Attributes {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
 }
 
 // WinningRecord 往年中奖记录
@@ -54,4 +78,5 @@ type WinningRecord struct {
 	ParticipantID int
 	Year          int
 	PrizeLevel    int
+	Session       string `gorm:"index"` // 写入该记录的抽奖场次，用于重复保存时替换本场的记录
 }
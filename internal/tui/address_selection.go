@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/palemoky/lucky-day/internal/i18n"
+)
+
+// AddressSelectionModel lets the operator pick the network address encoded in the check-in QR code
+type AddressSelectionModel struct {
+	cursor     int
+	choices    []string
+	done       bool
+	translator *i18n.Translator
+	width      int
+	height     int
+}
+
+// NewAddressSelectionModel creates a new address selection model
+func NewAddressSelectionModel(translator *i18n.Translator, choices []string) AddressSelectionModel {
+	return AddressSelectionModel{choices: choices, translator: translator}
+}
+
+func (m AddressSelectionModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m AddressSelectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.choices)-1 {
+				m.cursor++
+			}
+		case "enter":
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m AddressSelectionModel) View() tea.View {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("86")).
+		MarginTop(2).
+		MarginBottom(1)
+
+	choiceStyle := lipgloss.NewStyle().
+		PaddingLeft(2)
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("170")).
+		Bold(true).
+		PaddingLeft(2)
+
+	s := titleStyle.Render(m.translator.T("qr.select_address")) + "\n\n"
+
+	for i, choice := range m.choices {
+		if m.cursor == i {
+			s += selectedStyle.Render(fmt.Sprintf("> %s", choice)) + "\n"
+		} else {
+			s += choiceStyle.Render(fmt.Sprintf("  %s", choice)) + "\n"
+		}
+	}
+
+	s += "\n" + lipgloss.NewStyle().Faint(true).Render(m.translator.T("mode.instruction"))
+
+	v := tea.NewView(lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		s,
+	))
+	v.AltScreen = true
+	return v
+}
+
+// SelectAddress shows the address selection screen and returns the index of
+// the selected choice, or quit when the operator pressed q
+func SelectAddress(translator *i18n.Translator, choices []string) (int, bool, error) {
+	finalModel, err := tea.NewProgram(NewAddressSelectionModel(translator, choices)).Run()
+	if err != nil {
+		return 0, false, err
+	}
+	if m, ok := finalModel.(AddressSelectionModel); ok && m.done {
+		return m.cursor, false, nil
+	}
+	return 0, true, nil
+}
@@ -0,0 +1,225 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/palemoky/lucky-day/internal/checkin"
+	"github.com/palemoky/lucky-day/internal/i18n"
+	model1 "github.com/palemoky/lucky-day/internal/model"
+)
+
+const (
+	// maxRecentCheckIns is how many of the latest check-ins the dashboard lists
+	maxRecentCheckIns = 10
+	// checkInPanelWidth is the width of the dashboard next to the QR code
+	checkInPanelWidth = 40
+	// CheckInExportFile is where the check-in list is exported
+	CheckInExportFile = "checkin_participants.xlsx"
+)
+
+// qrStyle draws the light modules of the QR code in white on black, so it
+// scans the same whatever the terminal theme
+var qrStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#FFFFFF")).
+	Background(lipgloss.Color("#000000"))
+
+// checkedInMsg is sent when a participant checks in
+type checkedInMsg model1.Participant
+
+// checkInTickMsg refreshes the elapsed time and the counters
+type checkInTickMsg time.Time
+
+// waitForCheckIn waits for the next participant reported by the check-in server
+func waitForCheckIn(ch <-chan model1.Participant) tea.Cmd {
+	return func() tea.Msg {
+		p, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return checkedInMsg(p)
+	}
+}
+
+func checkInTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return checkInTickMsg(t) })
+}
+
+// CheckInModel is the check-in dashboard: the QR code with the latest
+// check-ins, the counters and the elapsed time
+type CheckInModel struct {
+	server     *checkin.Server
+	translator *i18n.Translator
+	qrFile     string // PNG copy of the QR code, shown for printing or sharing
+	started    time.Time
+	now        time.Time
+	recent     []model1.Participant // Latest check-ins, newest first
+	count      int
+	duplicates int
+	status     string // Result of the last export
+	statusErr  bool
+	done       bool
+	width      int
+	height     int
+}
+
+// NewCheckInModel creates the check-in dashboard of a started server
+func NewCheckInModel(translator *i18n.Translator, server *checkin.Server, qrFile string) CheckInModel {
+	now := time.Now()
+	return CheckInModel{
+		server:     server,
+		translator: translator,
+		qrFile:     qrFile,
+		started:    now,
+		now:        now,
+		count:      server.GetParticipantCount(),
+	}
+}
+
+func (m CheckInModel) Init() tea.Cmd {
+	return tea.Batch(waitForCheckIn(m.server.GetNewParticipantChannel()), checkInTick())
+}
+
+func (m CheckInModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case checkedInMsg:
+		m.recent = append([]model1.Participant{model1.Participant(msg)}, m.recent...)
+		if len(m.recent) > maxRecentCheckIns {
+			m.recent = m.recent[:maxRecentCheckIns]
+		}
+		m.refresh()
+		return m, waitForCheckIn(m.server.GetNewParticipantChannel())
+
+	case checkInTickMsg:
+		m.now = time.Time(msg)
+		// Notifications are dropped when the channel is full, so count on the server
+		m.refresh()
+		return m, checkInTick()
+
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "enter":
+			m.done = true
+			return m, tea.Quit
+		case "p":
+			m.server.SetPaused(!m.server.Paused())
+		case "s":
+			if err := m.server.SaveToExcel(CheckInExportFile); err != nil {
+				m.status, m.statusErr = fmt.Sprintf("%s: %v", m.translator.T("qr.save_failed"), err), true
+			} else {
+				m.status, m.statusErr = fmt.Sprintf("%s: %s", m.translator.T("qr.saved"), CheckInExportFile), false
+			}
+		}
+	}
+	return m, nil
+}
+
+// refresh reads the counters from the server
+func (m *CheckInModel) refresh() {
+	m.count = m.server.GetParticipantCount()
+	m.duplicates = m.server.GetDuplicateCount()
+}
+
+func (m CheckInModel) View() tea.View {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("86"))
+	faint := lipgloss.NewStyle().Faint(true)
+
+	url := m.server.GetURL()
+	paused := m.server.Paused()
+
+	// Dashboard next to the QR code
+	var p strings.Builder
+	p.WriteString(titleStyle.Render(m.translator.T("qr.title")) + "\n\n")
+	if paused {
+		p.WriteString(errorStyle.Render(m.translator.T("qr.paused")) + "\n")
+	} else {
+		p.WriteString(m.translator.T("qr.instruction") + "\n")
+	}
+	p.WriteString(faint.Render(url) + "\n\n")
+	p.WriteString(winnerStyle.Render(fmt.Sprintf("%s: %d", m.translator.T("qr.count"), m.count)) + "\n")
+	fmt.Fprintf(&p, "%s: %d\n", m.translator.T("qr.duplicates"), m.duplicates)
+	fmt.Fprintf(&p, "%s: %s\n\n", m.translator.T("qr.elapsed"), formatElapsed(m.now.Sub(m.started)))
+
+	p.WriteString(focusedStyle.Render(m.translator.T("qr.recent")) + "\n")
+	if len(m.recent) == 0 {
+		p.WriteString(blurredStyle.Render("-") + "\n")
+	}
+	for _, r := range m.recent {
+		line := fmt.Sprintf("#%d %s", r.ID, r.Name)
+		if r.Department != "" {
+			line += blurredStyle.Render(" · " + r.Department)
+		}
+		p.WriteString(line + "\n")
+	}
+
+	if m.status != "" {
+		style := faint
+		if m.statusErr {
+			style = errorStyle
+		}
+		p.WriteString("\n" + style.Render(m.status) + "\n")
+	}
+	if m.qrFile != "" {
+		p.WriteString("\n" + faint.Render(fmt.Sprintf("%s: %s", m.translator.T("qr.qr_file"), m.qrFile)) + "\n")
+	}
+
+	pauseKey := m.translator.T("qr.pause")
+	if paused {
+		pauseKey = m.translator.T("qr.resume")
+	}
+	help := strings.Join([]string{m.translator.T("qr.start"), pauseKey, m.translator.T("qr.save"), m.translator.T("qr.quit")}, " | ")
+
+	// The QR code takes the rest of the window, leaving a line for the help
+	art, fits, err := checkin.TerminalQRCode(url, m.width-checkInPanelWidth-6, m.height-3)
+	var qr string
+	switch {
+	case err != nil:
+		qr = errorStyle.Render(err.Error())
+	case !fits:
+		qr = qrStyle.Render(art) + "\n" + errorStyle.Render(m.translator.T("qr.too_small"))
+	default:
+		qr = qrStyle.Render(art)
+	}
+
+	body := lipgloss.JoinHorizontal(lipgloss.Center,
+		qr,
+		lipgloss.NewStyle().Width(checkInPanelWidth).MarginLeft(4).Render(p.String()),
+	)
+	v := tea.NewView(lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		body+"\n\n"+helpStyle.Render(help),
+	))
+	v.AltScreen = true
+	return v
+}
+
+// formatElapsed formats the check-in duration as HH:MM:SS
+func formatElapsed(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// RunCheckIn shows the check-in dashboard until the operator starts the draw,
+// and returns false when the operator quit instead
+func RunCheckIn(translator *i18n.Translator, server *checkin.Server, qrFile string) (bool, error) {
+	finalModel, err := tea.NewProgram(NewCheckInModel(translator, server, qrFile)).Run()
+	if err != nil {
+		return false, err
+	}
+	m, ok := finalModel.(CheckInModel)
+	return ok && m.done, nil
+}
@@ -13,9 +13,11 @@ import (
 type LotteryMode string
 
 const (
-	ModeExcel LotteryMode = "excel"
-	ModeQR    LotteryMode = "qr"
-	ModeDB    LotteryMode = "db"
+	ModeExcel  LotteryMode = "excel"
+	ModeCSV    LotteryMode = "csv"
+	ModeQR     LotteryMode = "qr"
+	ModeDB     LotteryMode = "db"
+	ModeResume LotteryMode = "resume" // Resume the previous session from its journal
 )
 
 // ModeSelectionModel represents the mode selection screen
@@ -33,12 +35,18 @@ type ModeSelectionModel struct {
 func NewModeSelectionModel(translator *i18n.Translator) ModeSelectionModel {
 	return ModeSelectionModel{
 		cursor:     0,
-		choices:    []LotteryMode{ModeExcel, ModeQR, ModeDB},
+		choices:    []LotteryMode{ModeExcel, ModeCSV, ModeQR, ModeDB},
 		done:       false,
 		translator: translator,
 	}
 }
 
+// withResume offers resuming the previous session as the first choice
+func (m ModeSelectionModel) withResume() ModeSelectionModel {
+	m.choices = append([]LotteryMode{ModeResume}, m.choices...)
+	return m
+}
+
 func (m ModeSelectionModel) Init() tea.Cmd {
 	return nil
 }
@@ -94,10 +102,14 @@ func (m ModeSelectionModel) View() tea.View {
 		switch choice {
 		case ModeExcel:
 			name = m.translator.T("mode.excel")
+		case ModeCSV:
+			name = m.translator.T("mode.csv")
 		case ModeQR:
 			name = m.translator.T("mode.qr")
 		case ModeDB:
 			name = m.translator.T("mode.db")
+		case ModeResume:
+			name = m.translator.T("mode.resume")
 		}
 
 		if m.cursor == i {
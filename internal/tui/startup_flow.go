@@ -12,6 +12,7 @@ type StartupFlow struct {
 	langModel  LanguageSelectionModel
 	modeModel  ModeSelectionModel
 	translator *i18n.Translator
+	canResume  bool // Whether a previous session journal can be resumed
 
 	// Results
 	selectedLang i18n.Language
@@ -19,11 +20,12 @@ type StartupFlow struct {
 	userQuit     bool
 }
 
-// NewStartupFlow creates a new startup flow
-func NewStartupFlow() StartupFlow {
+// NewStartupFlow creates a new startup flow; canResume offers resuming the previous session
+func NewStartupFlow(canResume bool) StartupFlow {
 	return StartupFlow{
 		stage:     0,
 		langModel: NewLanguageSelectionModel(),
+		canResume: canResume,
 	}
 }
 
@@ -57,6 +59,9 @@ func (m StartupFlow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.translator = i18n.NewTranslator(m.selectedLang)
 			m.stage = 1
 			m.modeModel = NewModeSelectionModel(m.translator)
+			if m.canResume {
+				m.modeModel = m.modeModel.withResume()
+			}
 			// Copy window size from language model to mode model
 			m.modeModel.width = m.langModel.width
 			m.modeModel.height = m.langModel.height
@@ -115,9 +120,10 @@ func (m StartupFlow) GetResults() (i18n.Language, LotteryMode, bool) {
 	return m.selectedLang, m.selectedMode, m.userQuit
 }
 
-// RunStartupFlow runs the unified startup flow
-func RunStartupFlow() (i18n.Language, LotteryMode, bool, error) {
-	m := NewStartupFlow()
+// RunStartupFlow runs the unified startup flow. When canResume is true the
+// mode selection also offers ModeResume to continue an interrupted session.
+func RunStartupFlow(canResume bool) (i18n.Language, LotteryMode, bool, error) {
+	m := NewStartupFlow(canResume)
 	p := tea.NewProgram(m)
 
 	finalModel, err := p.Run()
@@ -38,4 +38,9 @@ var (
 			Bold(true).
 		// Padding(1, 3).
 		Margin(0, 1)
+
+	// 中奖结果界面中被选中的中奖者
+	selectedWinnerBoxStyle = winnerBoxStyle.
+				BorderForeground(lipgloss.Color("205")).
+				Foreground(lipgloss.Color("205"))
 )
@@ -33,11 +33,19 @@ type model struct {
 	spinner        spinner.Model
 	rollingNames   []string // 抽奖动画中滚动的名字
 	currentWinners []model1.Participant
+	winnerCursor   int // 中奖结果界面中选中的中奖者索引
 	lastErr        string
+	save           SaveFunc
+	saveStatus     string // 最近一次自动保存的结果
+	saveErr        bool
 }
 
-// NewTUIModel 创建并初始化一个新的TUI模型
-func NewTUIModel(engine *lottery.Engine) *model {
+// SaveFunc 将当前的全部中奖结果保存到数据源，每次抽奖、弃奖补抽、重置、撤销或重做后调用。
+// 每次保存的都是完整结果，因此实现必须是幂等的：重复保存不会产生重复记录，撤销的结果会被删除。
+type SaveFunc func() error
+
+// NewTUIModel 创建并初始化一个新的TUI模型，save 为 nil 时不自动保存
+func NewTUIModel(engine *lottery.Engine, save SaveFunc) *model {
 	s := spinner.New()
 	s.Spinner = spinner.Globe
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
@@ -45,6 +53,7 @@ func NewTUIModel(engine *lottery.Engine) *model {
 		engine:  engine,
 		state:   statePrizeSelection,
 		spinner: s,
+		save:    save,
 	}
 }
 
@@ -65,9 +74,9 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 
+		// 只滚动本批要抽取的人数
 		prize := m.engine.GetPrizes()[m.cursor]
-		count := prize.Count - prize.DrawnCount
-		m.rollingNames = m.engine.GetRandomNames(count)
+		m.rollingNames = m.engine.GetRandomNames(m.engine.NextBatch(prize.ID))
 
 		return m, tea.Batch(cmd, tick())
 	}
@@ -110,14 +119,99 @@ func (m *model) updatePrizeSelection(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 		m.lastErr = ""
 		m.state = stateDrawing
 		return m, tick()
+	case "+", "=", "-":
+		m.adjustBatch(prizes[m.cursor], msg.String() != "-")
 	case "r":
 		prizeToReset := prizes[m.cursor]
 		m.engine.ResetPrize(prizeToReset.ID)
 		m.lastErr = fmt.Sprintf("提示: [%s] 已重置。", prizeToReset.Name)
+		m.autoSave()
+	case "u":
+		m.undo()
+	case "ctrl+r":
+		if ev, err := m.engine.Redo(); err != nil {
+			m.lastErr = fmt.Sprintf("提示: %v。", err)
+		} else {
+			m.lastErr = fmt.Sprintf("提示: 已重做 %s。", m.describeEvent(ev))
+			m.autoSave()
+		}
 	}
 	return m, nil
 }
 
+// adjustBatch 将奖项的每批人数加一或减一，范围为 1 到奖品总数，等于总数时一次抽完
+func (m *model) adjustBatch(prize model1.Prize, increase bool) {
+	size := m.engine.BatchSize(prize.ID)
+	if size == 0 {
+		size = prize.Count
+	}
+	if increase {
+		size++
+	} else {
+		size--
+	}
+	size = max(1, min(size, prize.Count))
+	if size == prize.Count {
+		size = 0
+	}
+	if err := m.engine.SetBatchSize(prize.ID, size); err != nil {
+		m.lastErr = fmt.Sprintf("错误: %v", err)
+		return
+	}
+	m.lastErr = ""
+}
+
+// batchLabel 返回奖项每批人数的说明
+func (m *model) batchLabel(prize model1.Prize) string {
+	if size := m.engine.BatchSize(prize.ID); size > 0 && size < prize.Count {
+		return fmt.Sprintf("每批 %d 人", size)
+	}
+	return "一次抽完"
+}
+
+// undo 撤销上一次操作并提示撤销了什么
+func (m *model) undo() {
+	if ev, err := m.engine.Undo(); err != nil {
+		m.lastErr = fmt.Sprintf("提示: %v。", err)
+	} else {
+		m.lastErr = fmt.Sprintf("提示: 已撤销 %s，按 ctrl+r 可重做。", m.describeEvent(ev))
+		m.autoSave()
+	}
+}
+
+// autoSave 保存当前的中奖结果并记录结果，供页脚显示
+func (m *model) autoSave() {
+	if m.save == nil {
+		return
+	}
+	if err := m.save(); err != nil {
+		m.saveStatus = fmt.Sprintf("自动保存失败: %v", err)
+		m.saveErr = true
+		return
+	}
+	m.saveStatus = fmt.Sprintf("已自动保存 %s", m.engine.Now().Format("15:04:05"))
+	m.saveErr = false
+}
+
+// describeEvent 返回操作的简短描述，用于撤销、重做提示
+func (m *model) describeEvent(ev lottery.Event) string {
+	name := fmt.Sprintf("#%d", ev.PrizeID)
+	for _, p := range m.engine.GetPrizes() {
+		if p.ID == ev.PrizeID {
+			name = p.Name
+		}
+	}
+	switch ev.Op {
+	case lottery.OpDraw:
+		return fmt.Sprintf("[%s] 的抽奖", name)
+	case lottery.OpReset:
+		return fmt.Sprintf("[%s] 的重置", name)
+	case lottery.OpForfeit:
+		return fmt.Sprintf("[%s] 的弃奖补抽", name)
+	}
+	return fmt.Sprintf("[%s] 的操作", name)
+}
+
 // 处理抽奖动画界面的按键
 func (m *model) updateDrawing(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -125,12 +219,17 @@ func (m *model) updateDrawing(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	default:
 		prize := m.engine.GetPrizes()[m.cursor]
-		winners, ok := m.engine.Draw(prize.ID)
-		if !ok {
-			m.lastErr = "抽奖失败，可能没有足够的候选人。"
+		winners, err := m.engine.Draw(prize.ID)
+		if err != nil {
+			// 抽奖失败时返回奖项列表并说明原因
+			m.lastErr = fmt.Sprintf("抽奖失败: %v", err)
+			m.state = statePrizeSelection
+			return m, nil
 		}
 		m.currentWinners = winners
+		m.winnerCursor = 0
 		m.state = stateShowWinners
+		m.autoSave()
 		return m, nil
 	}
 }
@@ -140,11 +239,55 @@ func (m *model) updateShowWinners(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c", "q":
 		return m, tea.Quit
+	case "left", "h":
+		if m.winnerCursor > 0 {
+			m.winnerCursor--
+		}
+	case "right", "l":
+		if m.winnerCursor < len(m.currentWinners)-1 {
+			m.winnerCursor++
+		}
+	case "f", "F":
+		// f: 弃奖并补抽，弃奖者仍可参与后续奖项；F: 同时取消其后续抽奖资格
+		if len(m.currentWinners) == 0 {
+			return m, nil
+		}
+		prize := m.engine.GetPrizes()[m.cursor]
+		absent := m.currentWinners[m.winnerCursor]
+		excludeFuture := msg.String() == "F"
+		replacement, err := m.engine.Forfeit(prize.ID, absent.ID, excludeFuture)
+		if err != nil {
+			m.lastErr = fmt.Sprintf("补抽失败: %v", err)
+			return m, nil
+		}
+		m.currentWinners[m.winnerCursor] = replacement
+		m.lastErr = fmt.Sprintf("提示: %s 已弃奖，补抽 %s。", absent.Name, replacement.Name)
+		if excludeFuture {
+			m.lastErr = fmt.Sprintf("提示: %s 已弃奖并取消后续抽奖资格，补抽 %s。", absent.Name, replacement.Name)
+		}
+		m.autoSave()
+	case "u":
+		// 撤销后界面上的中奖结果已不再有效，回到奖项列表
+		m.undo()
+		m.currentWinners = nil
+		m.state = statePrizeSelection
+	case "enter", "space":
+		// 分批抽取时直接抽下一批，名额已满则返回奖项列表
+		prize := m.engine.GetPrizes()[m.cursor]
+		m.currentWinners = nil
+		m.lastErr = ""
+		if prize.DrawnCount >= prize.Count {
+			m.state = statePrizeSelection
+			return m, nil
+		}
+		m.state = stateDrawing
+		return m, tick()
 	default:
 		m.currentWinners = nil
+		m.lastErr = ""
 		m.state = statePrizeSelection
-		return m, nil
 	}
+	return m, nil
 }
 
 func (m *model) View() tea.View {
@@ -233,7 +376,7 @@ func (m *model) viewPrizeSelection() string {
 		if m.cursor == i {
 			cursor = ">"
 		}
-		status := fmt.Sprintf("(%d/%d)", p.DrawnCount, p.Count)
+		status := fmt.Sprintf("(%d/%d) %s", p.DrawnCount, p.Count, m.batchLabel(p))
 		line := fmt.Sprintf("%s %s %s", cursor, p.Name, status)
 		if m.cursor == i {
 			s.WriteString(focusedStyle.Render(line))
@@ -243,6 +386,20 @@ func (m *model) viewPrizeSelection() string {
 		s.WriteString("\n")
 	}
 
+	// 预览当前奖项的参与条件和符合条件的人数
+	if len(prizes) > 0 {
+		selected := prizes[m.cursor]
+		if len(selected.Eligibility) > 0 {
+			s.WriteString("\n" + blurredStyle.Render("参与条件: "+strings.Join(selected.Eligibility, "; ")))
+		}
+		if count, err := m.engine.EligibleCount(selected.ID); err != nil {
+			s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("参与条件有误: %v", err)))
+		} else {
+			s.WriteString("\n" + blurredStyle.Render(fmt.Sprintf("符合条件: %d 人", count)))
+		}
+		s.WriteString("\n")
+	}
+
 	if m.lastErr != "" {
 		s.WriteString("\n" + errorStyle.Render(m.lastErr))
 	}
@@ -255,7 +412,8 @@ func (m *model) viewDrawing() string {
 	var s strings.Builder
 	prize := m.engine.GetPrizes()[m.cursor]
 
-	fmt.Fprintf(&s, "正在抽取 [%s] ... %s\n\n", prize.Name, m.spinner.View())
+	fmt.Fprintf(&s, "正在抽取 [%s] (%d/%d) 本批 %d 人 ... %s\n\n",
+		prize.Name, prize.DrawnCount, prize.Count, m.engine.NextBatch(prize.ID), m.spinner.View())
 
 	var winnerBlocks []string
 	for i, name := range m.rollingNames {
@@ -285,20 +443,37 @@ func (m *model) viewShowWinners() string {
 	if len(m.currentWinners) == 0 {
 		fmt.Fprintf(&s, "很遗憾，[%s] 本次无人中奖。\n", prize.Name)
 	} else {
-		fmt.Fprintf(&s, "🎉 恭喜以下人员获得 [%s] 🎉\n\n", prize.Name)
+		fmt.Fprintf(&s, "🎉 恭喜以下人员获得 [%s] 🎉 (%d/%d)\n\n", prize.Name, prize.DrawnCount, prize.Count)
+		ellipsis := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Padding(1, 2).Render("...")
+
+		// 中奖者较多时只显示选中者附近的一段
+		start := max(0, m.winnerCursor-maxDisplayedWinners+1)
+		end := min(len(m.currentWinners), start+maxDisplayedWinners)
 		var winnerBlocks []string
-		for i, w := range m.currentWinners {
-			if i >= maxDisplayedWinners {
-				ellipsis := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Padding(1, 2).Render("...")
-				winnerBlocks = append(winnerBlocks, ellipsis)
-				break
+		if start > 0 {
+			winnerBlocks = append(winnerBlocks, ellipsis)
+		}
+		for i := start; i < end; i++ {
+			style := winnerBoxStyle
+			if i == m.winnerCursor {
+				style = selectedWinnerBoxStyle
 			}
-			winnerBlocks = append(winnerBlocks, winnerBoxStyle.Render(w.Name))
+			winnerBlocks = append(winnerBlocks, style.Render(winnerLabel(m.currentWinners[i])))
+		}
+		if end < len(m.currentWinners) {
+			winnerBlocks = append(winnerBlocks, ellipsis)
 		}
 		s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, winnerBlocks...))
 	}
 
-	s.WriteString("\n\n按下 [任意键] 返回奖项列表。")
+	if m.lastErr != "" {
+		s.WriteString("\n\n" + errorStyle.Render(m.lastErr))
+	}
+	if prize.DrawnCount < prize.Count {
+		fmt.Fprintf(&s, "\n\n按下 [Enter] 抽取下一批（%d 人），[其他键] 返回奖项列表。", m.engine.NextBatch(prize.ID))
+	} else {
+		s.WriteString("\n\n按下 [任意键] 返回奖项列表。")
+	}
 
 	return mainPanelStyle.Render(s.String())
 }
@@ -308,13 +483,28 @@ func (m *model) viewFooter() string {
 	var instructions string
 	switch m.state {
 	case statePrizeSelection:
-		instructions = "↑/↓: 选择 | Enter: 抽奖 | r: 重置当前奖项 | q: 退出"
+		instructions = "↑/↓: 选择 | Enter: 抽奖 | +/-: 每批人数 | r: 重置当前奖项 | u: 撤销 | ctrl+r: 重做 | q: 退出"
 	case stateDrawing:
 		instructions = "任意键: 停止抽奖 | q: 退出"
 	case stateShowWinners:
-		instructions = "任意键: 返回 | q: 退出"
+		instructions = "←/→: 选择中奖者 | f: 弃奖补抽 | F: 弃奖补抽并取消后续资格 | u: 撤销 | Enter: 下一批 | 其他键: 返回 | q: 退出"
+	}
+	footer := "\n" + instructions
+	// 种子在赛后才公开，抽奖期间只显示承诺值
+	if commitment := m.engine.Commitment(); commitment != "" {
+		footer += "\n承诺: " + commitment
+	}
+	if m.saveStatus != "" && !m.saveErr {
+		footer += "\n" + m.saveStatus
+	}
+	footer = helpStyle.Render(footer)
+	if m.saveErr {
+		footer += "\n" + errorStyle.Render(m.saveStatus)
 	}
-	return helpStyle.Render("\n" + instructions)
+	if err := m.engine.JournalErr(); err != nil {
+		footer += "\n" + errorStyle.Render(fmt.Sprintf("警告: %v，程序崩溃后将无法恢复之后的操作！", err))
+	}
+	return footer
 }
 
 type tickMsg time.Time
@@ -325,9 +515,17 @@ func tick() tea.Cmd {
 	})
 }
 
-// StartTUI 启动TUI程序
-func StartTUI(engine *lottery.Engine) error {
-	p := tea.NewProgram(NewTUIModel(engine))
+// StartTUI 启动TUI程序，save 用于每次操作后自动保存中奖结果，可以为 nil
+func StartTUI(engine *lottery.Engine, save SaveFunc) error {
+	p := tea.NewProgram(NewTUIModel(engine, save))
 	_, err := p.Run()
 	return err
 }
+
+// winnerLabel 中奖者卡片上显示的文字，有部门时显示在姓名下方
+func winnerLabel(p model1.Participant) string {
+	if p.Department == "" {
+		return p.Name
+	}
+	return p.Name + "\n" + blurredStyle.Render(p.Department)
+}
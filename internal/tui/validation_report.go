@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/palemoky/lucky-day/internal/i18n"
+	"github.com/palemoky/lucky-day/internal/validation"
+)
+
+// ValidationReportModel shows the pre-draw validation report and asks whether to continue
+type ValidationReportModel struct {
+	report     validation.Report
+	translator *i18n.Translator
+	offset     int // First issue shown when the list is longer than the window
+	proceed    bool
+	width      int
+	height     int
+}
+
+// NewValidationReportModel creates a new validation report screen
+func NewValidationReportModel(translator *i18n.Translator, report validation.Report) ValidationReportModel {
+	return ValidationReportModel{report: report, translator: translator}
+}
+
+func (m ValidationReportModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ValidationReportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.offset = min(m.offset, m.maxOffset())
+		return m, nil
+
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			if m.offset > 0 {
+				m.offset--
+			}
+		case "down", "j":
+			if m.offset < m.maxOffset() {
+				m.offset++
+			}
+		case "enter", "y":
+			m.proceed = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// visibleIssues returns how many issues fit in the window
+func (m ValidationReportModel) visibleIssues() int {
+	if m.height == 0 {
+		return len(m.report.Issues)
+	}
+	// Title, summary, hints and margins take about 12 lines
+	return max(m.height-12, 3)
+}
+
+func (m ValidationReportModel) maxOffset() int {
+	return max(len(m.report.Issues)-m.visibleIssues(), 0)
+}
+
+func (m ValidationReportModel) View() tea.View {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("86")).
+		MarginTop(2).
+		MarginBottom(1)
+	warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	faint := lipgloss.NewStyle().Faint(true)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.translator.T("validation.title")) + "\n\n")
+	fmt.Fprintf(&b, "%s: %d %s, %d %s\n", m.translator.T("validation.summary"),
+		m.report.Participants, m.translator.T("data.participants"),
+		m.report.Prizes, m.translator.T("data.prizes"))
+	fmt.Fprintf(&b, "%s %d %s, %d %s\n\n",
+		errorStyle.Render("●"), m.report.Count(validation.Error), m.translator.T("validation.errors"),
+		m.report.Count(validation.Warning), m.translator.T("validation.warnings"))
+
+	end := min(m.offset+m.visibleIssues(), len(m.report.Issues))
+	if m.offset > 0 {
+		b.WriteString(faint.Render("  ↑ ...") + "\n")
+	}
+	for _, issue := range m.report.Issues[m.offset:end] {
+		style := warningStyle
+		if issue.Severity == validation.Error {
+			style = errorStyle
+		}
+		line := fmt.Sprintf("%s: %s", m.translator.T("validation.kind."+string(issue.Kind)), issue.Subject)
+		if issue.Detail != "" {
+			line += faint.Render(" — " + issue.Detail)
+		}
+		b.WriteString(style.Render("  • ") + line + "\n")
+	}
+	if end < len(m.report.Issues) {
+		b.WriteString(faint.Render("  ↓ ...") + "\n")
+	}
+
+	if m.report.Count(validation.Error) > 0 {
+		b.WriteString("\n" + m.translator.T("validation.dedupe") + "\n")
+	}
+	b.WriteString("\n" + faint.Render(m.translator.T("validation.instruction")))
+
+	v := tea.NewView(lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		b.String(),
+	))
+	v.AltScreen = true
+	return v
+}
+
+// ShowValidationReport shows the report and returns whether the host chose to continue the draw
+func ShowValidationReport(translator *i18n.Translator, report validation.Report) (bool, error) {
+	finalModel, err := tea.NewProgram(NewValidationReportModel(translator, report)).Run()
+	if err != nil {
+		return false, err
+	}
+	m, ok := finalModel.(ValidationReportModel)
+	return ok && m.proceed, nil
+}
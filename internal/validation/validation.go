@@ -0,0 +1,148 @@
+// Package validation 在抽奖开始前检查名单和奖品，汇总成一份报告供主持人确认
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palemoky/lucky-day/internal/datasource"
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+// Severity 问题的严重程度
+type Severity int
+
+const (
+	Warning Severity = iota // 不影响抽奖，但结果可能与预期不同
+	Error                   // 不处理会导致抽奖出错
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Kind 问题的类型
+type Kind string
+
+const (
+	DuplicateID   Kind = "duplicate_id"   // 多名参与者使用同一 ID，继续时只保留第一名
+	DuplicateName Kind = "duplicate_name" // 多名参与者同名，可能是重复登记
+	BlankName     Kind = "blank_name"     // 参与者姓名为空
+	SkippedRow    Kind = "skipped_row"    // 无法解析而被跳过的行
+	IgnoredValue  Kind = "ignored_value"  // 行中无效而被忽略的可选列
+	ZeroCount     Kind = "zero_count"     // 奖品数量不大于 0，不会被抽取
+	InvalidLevel  Kind = "invalid_level"  // 奖品等级不在特等奖到五等奖之间
+)
+
+// Issue 报告中的一个问题
+type Issue struct {
+	Kind     Kind
+	Severity Severity
+	Subject  string // 问题涉及的对象，如 "ID 12"、奖品名称或数据源中的行
+	Detail   string // 补充说明，如重复的各条记录
+}
+
+func (i Issue) String() string {
+	if i.Detail == "" {
+		return fmt.Sprintf("[%s] %s: %s", i.Severity, i.Kind, i.Subject)
+	}
+	return fmt.Sprintf("[%s] %s: %s (%s)", i.Severity, i.Kind, i.Subject, i.Detail)
+}
+
+// Report 名单和奖品的检查结果
+type Report struct {
+	Participants int // 检查的参与者人数
+	Prizes       int // 检查的奖品数
+	Issues       []Issue
+}
+
+// Empty 报告没有任何问题
+func (r Report) Empty() bool {
+	return len(r.Issues) == 0
+}
+
+// Count 返回指定严重程度的问题数量
+func (r Report) Count(severity Severity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Check 检查名单和奖品，rowErrors 为数据源加载时无法解析的行，见 datasource.RowErrors。
+// 问题按数据源中的行、参与者、奖品的顺序排列
+func Check(participants []model.Participant, prizes []model.Prize, rowErrors []datasource.RowError) Report {
+	report := Report{Participants: len(participants), Prizes: len(prizes)}
+
+	for _, e := range rowErrors {
+		kind := IgnoredValue
+		if e.Skipped {
+			kind = SkippedRow
+		}
+		report.add(kind, Warning, fmt.Sprintf("%s #%d", e.Source, e.Row), e.Err.Error())
+	}
+
+	// 按首次出现的顺序报告重复项
+	var ids, names []string
+	byID := make(map[int][]string)
+	byName := make(map[string][]string)
+	for _, p := range participants {
+		name := strings.TrimSpace(p.Name)
+		if name == "" {
+			report.add(BlankName, Warning, fmt.Sprintf("ID %d", p.ID), "")
+		}
+
+		idKey := fmt.Sprintf("ID %d", p.ID)
+		if _, ok := byID[p.ID]; !ok {
+			ids = append(ids, idKey)
+		}
+		byID[p.ID] = append(byID[p.ID], describe(p))
+
+		if name == "" {
+			continue
+		}
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], fmt.Sprintf("ID %d", p.ID))
+	}
+	for _, p := range participants {
+		if entries := byID[p.ID]; len(entries) > 1 {
+			report.add(DuplicateID, Error, fmt.Sprintf("ID %d", p.ID), strings.Join(entries, ", "))
+			delete(byID, p.ID)
+		}
+	}
+	for _, name := range names {
+		if entries := byName[name]; len(entries) > 1 {
+			report.add(DuplicateName, Warning, name, strings.Join(entries, ", "))
+		}
+	}
+
+	for _, prize := range prizes {
+		if prize.Count <= 0 {
+			report.add(ZeroCount, Warning, prize.Name, fmt.Sprintf("count %d", prize.Count))
+		}
+		if prize.Level < model.PrizeLevelSpecial || prize.Level > model.PrizeLevel5 {
+			report.add(InvalidLevel, Warning, prize.Name, fmt.Sprintf("level %d", prize.Level))
+		}
+	}
+	return report
+}
+
+func (r *Report) add(kind Kind, severity Severity, subject, detail string) {
+	r.Issues = append(r.Issues, Issue{Kind: kind, Severity: severity, Subject: subject, Detail: detail})
+}
+
+// describe 返回参与者在重复项说明中的写法
+func describe(p model.Participant) string {
+	if p.Department == "" {
+		return fmt.Sprintf("%q", p.Name)
+	}
+	return fmt.Sprintf("%q (%s)", p.Name, p.Department)
+}
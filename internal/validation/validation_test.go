@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palemoky/lucky-day/internal/datasource"
+	"github.com/palemoky/lucky-day/internal/model"
+)
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name         string
+		participants []model.Participant
+		prizes       []model.Prize
+		rowErrors    []datasource.RowError
+		expected     []Issue
+	}{
+		{
+			name:         "没有问题",
+			participants: []model.Participant{{ID: 1, Name: "张三"}, {ID: 2, Name: "李四"}},
+			prizes:       []model.Prize{{Name: "一等奖", Level: model.PrizeLevel1, Count: 1}},
+		},
+		{
+			name: "重复ID",
+			participants: []model.Participant{
+				{ID: 1, Name: "张三", Department: "研发部"}, {ID: 2, Name: "李四"}, {ID: 1, Name: "王五"},
+			},
+			expected: []Issue{{Kind: DuplicateID, Severity: Error, Subject: "ID 1", Detail: `"张三" (研发部), "王五"`}},
+		},
+		{
+			name:         "重复姓名和空白姓名",
+			participants: []model.Participant{{ID: 1, Name: "张三"}, {ID: 2, Name: " "}, {ID: 3, Name: "张三 "}},
+			expected: []Issue{
+				{Kind: BlankName, Severity: Warning, Subject: "ID 2"},
+				{Kind: DuplicateName, Severity: Warning, Subject: "张三", Detail: "ID 1, ID 3"},
+			},
+		},
+		{
+			name: "无法解析的行",
+			rowErrors: []datasource.RowError{
+				{Source: "Participants", Row: 3, Skipped: true, Err: errors.New("invalid ID")},
+				{Source: "Participants", Row: 5, Err: errors.New("invalid weight")},
+			},
+			expected: []Issue{
+				{Kind: SkippedRow, Severity: Warning, Subject: "Participants #3", Detail: "invalid ID"},
+				{Kind: IgnoredValue, Severity: Warning, Subject: "Participants #5", Detail: "invalid weight"},
+			},
+		},
+		{
+			name: "奖品数量为零和等级无效",
+			prizes: []model.Prize{
+				{Name: "一等奖", Level: model.PrizeLevel1, Count: 0},
+				{Name: "纪念奖", Level: 9, Count: 10},
+			},
+			expected: []Issue{
+				{Kind: ZeroCount, Severity: Warning, Subject: "一等奖", Detail: "count 0"},
+				{Kind: InvalidLevel, Severity: Warning, Subject: "纪念奖", Detail: "level 9"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := Check(tc.participants, tc.prizes, tc.rowErrors)
+			assert.Equal(t, len(tc.participants), report.Participants)
+			assert.Equal(t, len(tc.prizes), report.Prizes)
+			assert.Equal(t, tc.expected, report.Issues)
+			assert.Equal(t, len(tc.expected) == 0, report.Empty())
+		})
+	}
+}
+
+func TestReport_Count(t *testing.T) {
+	report := Check(
+		[]model.Participant{{ID: 1, Name: "张三"}, {ID: 1, Name: "张三"}},
+		nil, nil,
+	)
+	assert.Equal(t, 1, report.Count(Error))
+	assert.Equal(t, 1, report.Count(Warning))
+}